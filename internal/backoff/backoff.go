@@ -0,0 +1,20 @@
+// Package backoff provides the backoff plugins wait for between retries
+package backoff
+
+import (
+	"math"
+	"time"
+)
+
+// Exponential returns 10^attempts milliseconds, up to max,
+// or no time at all before the first attempt
+func Exponential(attempts int, max time.Duration) time.Duration {
+	if attempts == 0 {
+		return time.Duration(0)
+	}
+	d := time.Duration(math.Pow(10, float64(attempts))) * time.Millisecond
+	if d > max || d <= 0 {
+		d = max
+	}
+	return d
+}
@@ -0,0 +1,25 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponential(t *testing.T) {
+	testData := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{0, 0},
+		{1, time.Millisecond * 10},
+		{3, time.Second},
+		{5, time.Minute},
+		{100, time.Minute},
+	}
+
+	for _, d := range testData {
+		if got := Exponential(d.attempts, time.Minute); got != d.expected {
+			t.Fatalf("Expected %v for %d attempts, got %v", d.expected, d.attempts, got)
+		}
+	}
+}
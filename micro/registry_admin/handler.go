@@ -0,0 +1,261 @@
+package registry_admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+)
+
+/*
+	The handler serves the following endpoints relative to the path:
+
+	GET    /services         list services
+	GET    /services/{name}  get a service
+	POST   /register         register the service in the body
+	POST   /deregister       deregister the service in the body
+	GET    /watch            stream watch results as server-sent events
+
+	Register and deregister are rejected unless an AuthFunc is set.
+*/
+
+type handler struct {
+	opts Options
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewHandler returns a handler serving the registry API
+func NewHandler(opts ...Option) http.Handler {
+	options := Options{
+		Path: DefaultPath,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return newHandler(options)
+}
+
+func newHandler(opts Options) *handler {
+	opts.Path = strings.TrimSuffix(opts.Path, "/")
+
+	return &handler{
+		opts: opts,
+	}
+}
+
+func (h *handler) registry() registry.Registry {
+	if h.opts.Registry != nil {
+		return h.opts.Registry
+	}
+	return registry.DefaultRegistry
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, &errorResponse{Error: err.Error()})
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, h.opts.Path+"/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, h.opts.Path)
+	write := path == "/register" || path == "/deregister"
+
+	if h.opts.Auth != nil {
+		if err := h.opts.Auth(r, write); err != nil {
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+	}
+
+	// writes are only allowed to authorised requests
+	if write && (h.opts.ReadOnly || h.opts.Auth == nil) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("registry is read only"))
+		return
+	}
+
+	switch {
+	case path == "/services":
+		h.listServices(w, r)
+	case strings.HasPrefix(path, "/services/"):
+		h.getService(w, r, strings.TrimPrefix(path, "/services/"))
+	case path == "/register":
+		h.register(w, r)
+	case path == "/deregister":
+		h.deregister(w, r)
+	case path == "/watch":
+		h.watch(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *handler) listServices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	services, err := h.registry().ListServices()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if services == nil {
+		services = []*registry.Service{}
+	}
+
+	writeJSON(w, http.StatusOK, services)
+}
+
+func (h *handler) getService(w http.ResponseWriter, r *http.Request, name string) {
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	services, err := h.registry().GetService(name)
+	if err == registry.ErrNotFound || (err == nil && len(services) == 0) {
+		writeError(w, http.StatusNotFound, registry.ErrNotFound)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, services)
+}
+
+// decode reads the service in the request body
+func decode(r *http.Request) (*registry.Service, error) {
+	if r.Method != "POST" {
+		return nil, fmt.Errorf("method %s not allowed", r.Method)
+	}
+
+	var service *registry.Service
+	if err := json.NewDecoder(r.Body).Decode(&service); err != nil {
+		return nil, err
+	}
+
+	if service == nil || len(service.Name) == 0 {
+		return nil, fmt.Errorf("service name required")
+	}
+	if len(service.Nodes) == 0 {
+		return nil, fmt.Errorf("service nodes required")
+	}
+
+	return service, nil
+}
+
+func (h *handler) register(w http.ResponseWriter, r *http.Request) {
+	service, err := decode(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var opts []registry.RegisterOption
+
+	// optional ttl e.g ?ttl=30s
+	if v := r.URL.Query().Get("ttl"); len(v) > 0 {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		opts = append(opts, registry.RegisterTTL(ttl))
+	}
+
+	if err := h.registry().Register(service, opts...); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, service)
+}
+
+func (h *handler) deregister(w http.ResponseWriter, r *http.Request) {
+	service, err := decode(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.registry().Deregister(service); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, service)
+}
+
+func (h *handler) watch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+
+	watcher, err := h.registry().Watch()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// stop the watcher when the client goes away
+	done := make(chan bool)
+	defer close(done)
+
+	go func() {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+		watcher.Stop()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		res, err := watcher.Next()
+		if err != nil {
+			return
+		}
+
+		b, err := json.Marshal(res.Service)
+		if err != nil {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", res.Action, b); err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
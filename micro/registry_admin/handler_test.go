@@ -0,0 +1,236 @@
+package registry_admin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/registry/mock"
+)
+
+type testWatcher struct {
+	results chan *registry.Result
+	exit    chan bool
+}
+
+func (w *testWatcher) Next() (*registry.Result, error) {
+	select {
+	case r := <-w.results:
+		return r, nil
+	case <-w.exit:
+		return nil, errors.New("watcher stopped")
+	}
+}
+
+func (w *testWatcher) Stop() {
+	close(w.exit)
+}
+
+type testRegistry struct {
+	registry.Registry
+	results chan *registry.Result
+}
+
+func (r *testRegistry) Watch() (registry.Watcher, error) {
+	return &testWatcher{results: r.results, exit: make(chan bool)}, nil
+}
+
+func allowAll(r *http.Request, write bool) error {
+	return nil
+}
+
+func TestHandler(t *testing.T) {
+	r := &testRegistry{Registry: mock.NewRegistry()}
+
+	s := httptest.NewServer(NewHandler(Registry(r), Auth(allowAll)))
+	defer s.Close()
+
+	rsp, err := http.Get(s.URL + "/registry/services/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rsp.StatusCode)
+	}
+
+	var services []*registry.Service
+	if err := json.NewDecoder(rsp.Body).Decode(&services); err != nil {
+		t.Fatal(err)
+	}
+
+	expected, _ := r.GetService("foo")
+	if len(services) != len(expected) {
+		t.Fatalf("Expected %d services, got %d", len(expected), len(services))
+	}
+
+	// register a node manually
+	b, _ := json.Marshal(&registry.Service{
+		Name:    "bar",
+		Version: "1.0.0",
+		Nodes:   []*registry.Node{{Id: "bar-1", Address: "10.0.0.1", Port: 8080}},
+	})
+
+	rsp, err = http.Post(s.URL+"/registry/register", "application/json", bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rsp.StatusCode)
+	}
+
+	if services, err := r.GetService("bar"); err != nil || len(services) != 1 {
+		t.Fatalf("Expected bar to be registered, got %v %v", services, err)
+	}
+
+	rsp, err = http.Post(s.URL+"/registry/deregister", "application/json", bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsp.Body.Close()
+
+	if services, _ := r.GetService("bar"); len(services) != 0 {
+		t.Fatalf("Expected bar to be deregistered, got %v", services)
+	}
+
+	rsp, err = http.Get(s.URL + "/registry/services/bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected status 404, got %d", rsp.StatusCode)
+	}
+}
+
+func TestHandlerWatch(t *testing.T) {
+	r := &testRegistry{
+		Registry: mock.NewRegistry(),
+		results:  make(chan *registry.Result, 1),
+	}
+
+	s := httptest.NewServer(NewHandler(Registry(r)))
+	defer s.Close()
+
+	rsp, err := http.Get(s.URL + "/registry/watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rsp.Body.Close()
+
+	if ct := rsp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected content type text/event-stream, got %s", ct)
+	}
+
+	r.results <- &registry.Result{Action: "create", Service: &registry.Service{Name: "foo"}}
+
+	reader := bufio.NewReader(rsp.Body)
+
+	event, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event != "event: create\n" {
+		t.Fatalf("Expected create event, got %q", event)
+	}
+
+	data, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var service *registry.Service
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(data, "data: ")), &service); err != nil {
+		t.Fatal(err)
+	}
+	if service.Name != "foo" {
+		t.Fatalf("Expected service foo, got %s", service.Name)
+	}
+}
+
+func TestHandlerAuth(t *testing.T) {
+	r := &testRegistry{Registry: mock.NewRegistry()}
+
+	s := httptest.NewServer(NewHandler(Registry(r), Auth(TokenAuth("secret")), ReadOnly()))
+	defer s.Close()
+
+	testData := []struct {
+		method string
+		path   string
+		token  string
+		status int
+	}{
+		{"GET", "/registry/services", "", http.StatusUnauthorized},
+		{"GET", "/registry/services", "wrong", http.StatusUnauthorized},
+		{"GET", "/registry/services", "secret", http.StatusOK},
+		{"POST", "/registry/register", "secret", http.StatusForbidden},
+	}
+
+	for _, test := range testData {
+		req, err := http.NewRequest(test.method, s.URL+test.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(test.token) > 0 {
+			req.Header.Set("Authorization", "Bearer "+test.token)
+		}
+
+		rsp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rsp.Body.Close()
+
+		if rsp.StatusCode != test.status {
+			t.Fatalf("Expected status %d for %s %s with token %q, got %d", test.status, test.method, test.path, test.token, rsp.StatusCode)
+		}
+	}
+}
+
+func TestHandlerReadOnlyDefault(t *testing.T) {
+	r := &testRegistry{Registry: mock.NewRegistry()}
+
+	s := httptest.NewServer(NewHandler(Registry(r)))
+	defer s.Close()
+
+	b, _ := json.Marshal(&registry.Service{
+		Name:  "bar",
+		Nodes: []*registry.Node{{Id: "bar-1", Address: "10.0.0.1", Port: 8080}},
+	})
+
+	for _, path := range []string{"/registry/register", "/registry/deregister"} {
+		rsp, err := http.Post(s.URL+path, "application/json", bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rsp.Body.Close()
+
+		if rsp.StatusCode != http.StatusForbidden {
+			t.Fatalf("Expected status 403 for %s without auth, got %d", path, rsp.StatusCode)
+		}
+	}
+
+	if services, _ := r.GetService("bar"); len(services) != 0 {
+		t.Fatalf("Expected bar not to be registered, got %v", services)
+	}
+
+	rsp, err := http.Get(s.URL + "/registry/services")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rsp.StatusCode)
+	}
+}
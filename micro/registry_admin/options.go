@@ -0,0 +1,54 @@
+package registry_admin
+
+import (
+	"net/http"
+
+	"github.com/micro/go-micro/registry"
+)
+
+type Options struct {
+	// Registry to serve, by default registry.DefaultRegistry
+	Registry registry.Registry
+	// Path the API is served on
+	Path string
+	// Auth is called before every request, without it
+	// register and deregister are disabled
+	Auth AuthFunc
+	// ReadOnly disables register and deregister even with Auth
+	ReadOnly bool
+}
+
+type Option func(o *Options)
+
+// AuthFunc authorises a request, returning an error rejects it. Write is
+// true for requests which change the registry.
+type AuthFunc func(r *http.Request, write bool) error
+
+// Registry sets the registry to serve
+func Registry(r registry.Registry) Option {
+	return func(o *Options) {
+		o.Registry = r
+	}
+}
+
+// Path sets the path the API is served on
+func Path(p string) Option {
+	return func(o *Options) {
+		o.Path = p
+	}
+}
+
+// Auth sets the function used to authorise requests, which also
+// enables register and deregister
+func Auth(fn AuthFunc) Option {
+	return func(o *Options) {
+		o.Auth = fn
+	}
+}
+
+// ReadOnly disables register and deregister even with Auth
+func ReadOnly() Option {
+	return func(o *Options) {
+		o.ReadOnly = true
+	}
+}
@@ -0,0 +1,118 @@
+// Package registry_admin is a micro plugin serving an HTTP API to inspect and repair the registry
+package registry_admin
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/micro/cli"
+	"github.com/micro/micro/plugin"
+)
+
+var (
+	// DefaultPath is the path the API is served on
+	DefaultPath = "/registry"
+)
+
+type registryAdmin struct {
+	opts    Options
+	handler http.Handler
+}
+
+func (ra *registryAdmin) Flags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   "registry_admin_path",
+			Usage:  "Path the registry API is served on. Defaults to " + DefaultPath,
+			EnvVar: "REGISTRY_ADMIN_PATH",
+		},
+		cli.StringFlag{
+			Name:   "registry_admin_token",
+			Usage:  "Bearer token required to access the registry API. Register and deregister are disabled without it",
+			EnvVar: "REGISTRY_ADMIN_TOKEN",
+		},
+		cli.BoolFlag{
+			Name:   "registry_admin_read_only",
+			Usage:  "Disable register and deregister through the registry API",
+			EnvVar: "REGISTRY_ADMIN_READ_ONLY",
+		},
+	}
+}
+
+func (ra *registryAdmin) Commands() []cli.Command {
+	return nil
+}
+
+func (ra *registryAdmin) Handler() plugin.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != ra.opts.Path && !strings.HasPrefix(r.URL.Path, ra.opts.Path+"/") {
+				h.ServeHTTP(w, r)
+				return
+			}
+			ra.handler.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (ra *registryAdmin) Init(ctx *cli.Context) error {
+	if p := ctx.String("registry_admin_path"); len(p) > 0 {
+		ra.opts.Path = p
+	}
+
+	if ctx.Bool("registry_admin_read_only") {
+		ra.opts.ReadOnly = true
+	}
+
+	// the token is only used if no auth func was set
+	if t := ctx.String("registry_admin_token"); len(t) > 0 && ra.opts.Auth == nil {
+		ra.opts.Auth = TokenAuth(t)
+	}
+
+	ra.init()
+	return nil
+}
+
+func (ra *registryAdmin) init() {
+	ra.opts.Path = "/" + strings.Trim(ra.opts.Path, "/")
+	ra.handler = newHandler(ra.opts)
+}
+
+func (ra *registryAdmin) String() string {
+	return "registry_admin"
+}
+
+// TokenAuth returns an AuthFunc which requires the bearer token
+func TokenAuth(token string) AuthFunc {
+	return func(r *http.Request, write bool) error {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") {
+			return errors.New("bearer token required")
+		}
+		if subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) != 1 {
+			return errors.New("invalid bearer token")
+		}
+		return nil
+	}
+}
+
+// NewPlugin creates a plugin serving the registry API, by
+// default for registry.DefaultRegistry on DefaultPath
+func NewPlugin(opts ...Option) plugin.Plugin {
+	options := Options{
+		Path: DefaultPath,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	ra := &registryAdmin{
+		opts: options,
+	}
+	ra.init()
+
+	return ra
+}
@@ -0,0 +1,287 @@
+// Package cache provides a registry which caches the services of another registry
+package cache
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/registry"
+)
+
+/*
+	The cache wraps a registry and keeps the services returned by GetService
+	in memory. The cache is kept up to date through the wrapped registry's
+	Watch and entries are refreshed from the registry once their TTL has passed.
+	If the registry returns an error, stale entries are returned instead.
+*/
+
+// Cache is a registry which caches services
+type Cache interface {
+	registry.Registry
+	// Stats returns the cache statistics
+	Stats() Stats
+	// Stop the cache watcher
+	Stop()
+}
+
+// Stats are the cache hit and miss statistics
+type Stats struct {
+	// Hits is the number of lookups served from the cache
+	Hits uint64
+	// Misses is the number of lookups sent to the registry
+	Misses uint64
+	// Stale is the number of lookups served from the cache after the registry failed
+	Stale uint64
+}
+
+type Options struct {
+	// TTL is how long services are cached before being refreshed
+	TTL time.Duration
+}
+
+type Option func(o *Options)
+
+type cache struct {
+	registry.Registry
+	opts Options
+
+	sync.RWMutex
+	cache   map[string][]*registry.Service
+	ttls    map[string]time.Time
+	stats   Stats
+	watched bool
+	exit    chan bool
+}
+
+var (
+	// DefaultTTL is how long services are cached for
+	DefaultTTL = time.Minute
+)
+
+// TTL sets how long services are cached before being refreshed from the registry
+func TTL(t time.Duration) Option {
+	return func(o *Options) {
+		o.TTL = t
+	}
+}
+
+func backoff(attempts int) time.Duration {
+	if attempts == 0 {
+		return time.Duration(0)
+	}
+	d := time.Duration(math.Pow(10, float64(attempts))) * time.Millisecond
+	if d > time.Minute {
+		d = time.Minute
+	}
+	return d
+}
+
+func (c *cache) quit() bool {
+	select {
+	case <-c.exit:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *cache) get(name string) ([]*registry.Service, error) {
+	c.Lock()
+	services, ok := c.cache[name]
+	if ok && time.Now().Before(c.ttls[name]) {
+		c.stats.Hits++
+		services = copyServices(services)
+		c.Unlock()
+		return services, nil
+	}
+	c.stats.Misses++
+	c.Unlock()
+
+	rsp, err := c.Registry.GetService(name)
+	if err != nil {
+		// serve stale entries if the registry failed
+		if ok && err != registry.ErrNotFound {
+			c.Lock()
+			c.stats.Stale++
+			services = copyServices(c.cache[name])
+			c.Unlock()
+			if len(services) > 0 {
+				return services, nil
+			}
+		}
+		return nil, err
+	}
+
+	c.Lock()
+	c.cache[name] = copyServices(rsp)
+	c.ttls[name] = time.Now().Add(c.opts.TTL)
+
+	// start the watcher on the first lookup
+	if !c.watched {
+		c.watched = true
+		go c.run()
+	}
+	c.Unlock()
+
+	return rsp, nil
+}
+
+func (c *cache) update(res *registry.Result) {
+	if res == nil || res.Service == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	services, ok := c.cache[res.Service.Name]
+	if !ok {
+		// we're not caching this service
+		return
+	}
+
+	var service *registry.Service
+	var index int
+	for i, s := range services {
+		if s.Version == res.Service.Version {
+			service = s
+			index = i
+			break
+		}
+	}
+
+	switch res.Action {
+	case "create", "update":
+		if service == nil {
+			c.cache[res.Service.Name] = append(services, copyService(res.Service))
+			return
+		}
+		service.Nodes = addNodes(service.Nodes, res.Service.Nodes)
+	case "delete":
+		if service == nil {
+			return
+		}
+
+		service.Nodes = delNodes(service.Nodes, res.Service.Nodes)
+		if len(service.Nodes) > 0 {
+			return
+		}
+
+		// no nodes left so remove the version
+		if len(services) > 1 {
+			c.cache[res.Service.Name] = append(services[:index], services[index+1:]...)
+			return
+		}
+
+		delete(c.cache, res.Service.Name)
+		delete(c.ttls, res.Service.Name)
+	}
+}
+
+// run watches the registry and keeps the cache up to date
+func (c *cache) run() {
+	var a, b int
+
+	for {
+		if c.quit() {
+			return
+		}
+
+		// wait before retrying
+		select {
+		case <-c.exit:
+			return
+		case <-time.After(backoff(a + b)):
+		}
+
+		w, err := c.Registry.Watch()
+		if err != nil {
+			a++
+			log.Logf("cache: error creating watcher: %v", err)
+			continue
+		}
+		a = 0
+
+		if err := c.watch(w); err != nil {
+			b++
+			log.Logf("cache: error watching registry: %v", err)
+			continue
+		}
+		b = 0
+	}
+}
+
+func (c *cache) watch(w registry.Watcher) error {
+	defer w.Stop()
+
+	// stop the watcher on exit
+	done := make(chan bool)
+	defer close(done)
+
+	go func() {
+		select {
+		case <-c.exit:
+			w.Stop()
+		case <-done:
+		}
+	}()
+
+	for {
+		res, err := w.Next()
+		if err != nil {
+			// events may have been missed so expire all entries,
+			// they're still served if the registry is unavailable
+			c.Lock()
+			for name := range c.ttls {
+				c.ttls[name] = time.Time{}
+			}
+			c.Unlock()
+			return err
+		}
+		c.update(res)
+	}
+}
+
+func (c *cache) GetService(name string) ([]*registry.Service, error) {
+	return c.get(name)
+}
+
+func (c *cache) Stats() Stats {
+	c.RLock()
+	defer c.RUnlock()
+	return c.stats
+}
+
+func (c *cache) Stop() {
+	select {
+	case <-c.exit:
+		return
+	default:
+		close(c.exit)
+	}
+}
+
+func (c *cache) String() string {
+	return "cache"
+}
+
+// New returns a registry which caches the services of r
+func New(r registry.Registry, opts ...Option) Cache {
+	options := Options{
+		TTL: DefaultTTL,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return &cache{
+		Registry: r,
+		opts:     options,
+		cache:    make(map[string][]*registry.Service),
+		ttls:     make(map[string]time.Time),
+		exit:     make(chan bool),
+	}
+}
@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-plugins/registry/memory"
+)
+
+// errRegistry fails GetService when err is set
+type errRegistry struct {
+	registry.Registry
+	err error
+}
+
+func (e *errRegistry) GetService(name string) ([]*registry.Service, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.Registry.GetService(name)
+}
+
+func testService(id string) *registry.Service {
+	return &registry.Service{
+		Name:    "foo",
+		Version: "1.0.0",
+		Nodes: []*registry.Node{
+			{
+				Id:      id,
+				Address: "localhost",
+				Port:    8080,
+			},
+		},
+	}
+}
+
+func TestCache(t *testing.T) {
+	m := memory.NewRegistry()
+	m.Register(testService("foo-1"))
+
+	c := New(m)
+	defer c.Stop()
+
+	for i := 0; i < 3; i++ {
+		services, err := c.GetService("foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(services) != 1 || len(services[0].Nodes) != 1 {
+			t.Fatalf("Expected 1 service with 1 node, got %+v", services)
+		}
+	}
+
+	if stats := c.Stats(); stats.Hits != 2 || stats.Misses != 1 {
+		t.Fatalf("Expected 2 hits and 1 miss, got %+v", stats)
+	}
+
+	if _, err := c.GetService("bar"); err != registry.ErrNotFound {
+		t.Fatalf("Expected %v, got %v", registry.ErrNotFound, err)
+	}
+}
+
+func TestCacheWatch(t *testing.T) {
+	m := memory.NewRegistry()
+	m.Register(testService("foo-1"))
+
+	c := New(m)
+	defer c.Stop()
+
+	if _, err := c.GetService("foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	// changes are picked up by the watcher
+	check := func(nodes int) {
+		deadline := time.Now().Add(time.Second)
+		for {
+			services, err := c.GetService("foo")
+			if err == nil && len(services) == 1 && len(services[0].Nodes) == nodes {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %d nodes, got %+v %v", nodes, services, err)
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+	}
+
+	// give the watcher time to start
+	time.Sleep(time.Millisecond * 50)
+
+	m.Register(testService("foo-2"))
+	check(2)
+
+	m.Deregister(testService("foo-1"))
+	check(1)
+
+	if stats := c.Stats(); stats.Misses != 1 {
+		t.Fatalf("Expected cache to be kept up to date by the watcher, got %+v", stats)
+	}
+}
+
+func TestCacheStale(t *testing.T) {
+	m := memory.NewRegistry()
+	m.Register(testService("foo-1"))
+
+	e := &errRegistry{Registry: m}
+
+	c := New(e, TTL(time.Millisecond))
+	defer c.Stop()
+
+	if _, err := c.GetService("foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(time.Millisecond * 10)
+	e.err = errors.New("unavailable")
+
+	services, err := c.GetService("foo")
+	if err != nil {
+		t.Fatalf("Expected stale services, got %v", err)
+	}
+	if len(services) != 1 || len(services[0].Nodes) != 1 {
+		t.Fatalf("Expected 1 service with 1 node, got %+v", services)
+	}
+
+	if stats := c.Stats(); stats.Stale != 1 || stats.Misses != 2 {
+		t.Fatalf("Expected 1 stale lookup and 2 misses, got %+v", stats)
+	}
+
+	// nothing is cached for bar
+	if _, err := c.GetService("bar"); err == nil {
+		t.Fatal("Expected error for uncached service")
+	}
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"github.com/micro/go-micro/registry"
+)
+
+func addNodes(old, neu []*registry.Node) []*registry.Node {
+	for _, n := range neu {
+		var seen bool
+		for i, o := range old {
+			if o.Id == n.Id {
+				seen = true
+				old[i] = n
+				break
+			}
+		}
+		if !seen {
+			old = append(old, n)
+		}
+	}
+	return old
+}
+
+func delNodes(old, del []*registry.Node) []*registry.Node {
+	var nodes []*registry.Node
+	for _, o := range old {
+		var rem bool
+		for _, n := range del {
+			if o.Id == n.Id {
+				rem = true
+				break
+			}
+		}
+		if !rem {
+			nodes = append(nodes, o)
+		}
+	}
+	return nodes
+}
+
+func copyService(s *registry.Service) *registry.Service {
+	service := new(registry.Service)
+	*service = *s
+	service.Nodes = make([]*registry.Node, len(s.Nodes))
+	copy(service.Nodes, s.Nodes)
+	return service
+}
+
+func copyServices(services []*registry.Service) []*registry.Service {
+	var cp []*registry.Service
+	for _, s := range services {
+		cp = append(cp, copyService(s))
+	}
+	return cp
+}
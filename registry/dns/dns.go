@@ -0,0 +1,495 @@
+// Package dns provides a registry using DNS SRV and TXT records
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/cmd"
+	"github.com/micro/go-micro/registry"
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+)
+
+/*
+	Services are resolved by looking up the SRV records for _<service>._tcp.<domain>.
+	Each SRV target is a node. The TXT records of the target hold key=value pairs;
+	"id" and "version" set the node id and service version, anything else is node metadata.
+
+	Services are listed through DNS-SD PTR records at _services._dns-sd._udp.<domain>.
+
+	The registry is read only unless dynamic updates (RFC 2136) are enabled, in which
+	case registering a node writes SRV, A/AAAA, TXT and PTR records to the domain's zone.
+*/
+
+type dnsRegistry struct {
+	opts     registry.Options
+	client   *dns.Client
+	servers  []string
+	domain   string
+	interval time.Duration
+	update   bool
+	tsig     *tsig
+
+	sync.RWMutex
+	// names of services looked up which are polled by watchers
+	names map[string]bool
+}
+
+type tsig struct {
+	name   string
+	secret string
+}
+
+var (
+	// DefaultDomain is the domain services are looked up in
+	DefaultDomain = "micro.local"
+	// DefaultInterval is how often watchers poll for changes
+	DefaultInterval = time.Second * 10
+	// DefaultTTL is the TTL of records written by dynamic updates
+	DefaultTTL = time.Minute
+
+	// ErrReadOnly is returned by Register and Deregister when dynamic updates are disabled
+	ErrReadOnly = errors.New("dns registry is read only, dynamic updates are not enabled")
+)
+
+func init() {
+	cmd.DefaultRegistries["dns"] = NewRegistry
+}
+
+func serviceName(service, domain string) string {
+	return dns.Fqdn("_" + service + "._tcp." + domain)
+}
+
+func browseName(domain string) string {
+	return dns.Fqdn("_services._dns-sd._udp." + domain)
+}
+
+func nodeName(id, domain string) string {
+	return dns.Fqdn(strings.Replace(id, ".", "-", -1) + "." + domain)
+}
+
+func header(name string, rrtype uint16, ttl uint32) dns.RR_Header {
+	return dns.RR_Header{Name: name, Rrtype: rrtype, Class: dns.ClassINET, Ttl: ttl}
+}
+
+func encodeTXT(version string, node *registry.Node) []string {
+	txt := []string{"id=" + node.Id, "version=" + version}
+	for k, v := range node.Metadata {
+		txt = append(txt, k+"="+v)
+	}
+	return txt
+}
+
+func decodeTXT(txt []string, node *registry.Node) string {
+	var version string
+	for _, t := range txt {
+		parts := strings.SplitN(t, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		switch parts[0] {
+		case "id":
+			node.Id = parts[1]
+		case "version":
+			version = parts[1]
+		default:
+			if node.Metadata == nil {
+				node.Metadata = make(map[string]string)
+			}
+			node.Metadata[parts[0]] = parts[1]
+		}
+	}
+	return version
+}
+
+func (d *dnsRegistry) exchange(m *dns.Msg) (*dns.Msg, error) {
+	if d.tsig != nil {
+		m.SetTsig(d.tsig.name, dns.HmacSHA256, 300, time.Now().Unix())
+	}
+
+	var gerr error
+	for _, server := range d.servers {
+		rsp, _, err := d.client.Exchange(m, server)
+		if err != nil {
+			gerr = err
+			continue
+		}
+		return rsp, nil
+	}
+	return nil, gerr
+}
+
+func (d *dnsRegistry) query(name string, qtype uint16) (*dns.Msg, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(name, qtype)
+	m.RecursionDesired = true
+
+	rsp, err := d.exchange(m)
+	if err != nil {
+		return nil, err
+	}
+
+	switch rsp.Rcode {
+	case dns.RcodeSuccess:
+		return rsp, nil
+	case dns.RcodeNameError:
+		return nil, registry.ErrNotFound
+	default:
+		return nil, fmt.Errorf("dns query for %s failed: %s", name, dns.RcodeToString[rsp.Rcode])
+	}
+}
+
+// srvs returns the SRV records of the service
+func (d *dnsRegistry) srvs(service string) ([]*dns.SRV, error) {
+	rsp, err := d.query(serviceName(service, d.domain), dns.TypeSRV)
+	if err == registry.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var srvs []*dns.SRV
+	for _, rr := range rsp.Answer {
+		if srv, ok := rr.(*dns.SRV); ok {
+			srvs = append(srvs, srv)
+		}
+	}
+	return srvs, nil
+}
+
+// targets returns the SRV records of the service pointing at target
+func (d *dnsRegistry) targets(service, target string) ([]dns.RR, error) {
+	srvs, err := d.srvs(service)
+	if err != nil {
+		return nil, err
+	}
+
+	var rrs []dns.RR
+	for _, srv := range srvs {
+		if strings.EqualFold(srv.Target, target) {
+			rrs = append(rrs, srv)
+		}
+	}
+	return rrs, nil
+}
+
+func (d *dnsRegistry) sendUpdate(m *dns.Msg) error {
+	rsp, err := d.exchange(m)
+	if err != nil {
+		return err
+	}
+	if rsp.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("dns update failed: %s", dns.RcodeToString[rsp.Rcode])
+	}
+	return nil
+}
+
+func (d *dnsRegistry) Register(s *registry.Service, opts ...registry.RegisterOption) error {
+	if !d.update {
+		return ErrReadOnly
+	}
+
+	if len(s.Nodes) == 0 {
+		return errors.New("Require at least one node")
+	}
+
+	var options registry.RegisterOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	ttl := uint32(DefaultTTL.Seconds())
+	if options.TTL.Seconds() > 0 {
+		ttl = uint32(options.TTL.Seconds())
+	}
+
+	name := serviceName(s.Name, d.domain)
+
+	m := new(dns.Msg)
+	m.SetUpdate(dns.Fqdn(d.domain))
+
+	rrs := []dns.RR{
+		&dns.PTR{Hdr: header(browseName(d.domain), dns.TypePTR, ttl), Ptr: name},
+	}
+
+	for _, node := range s.Nodes {
+		ip := net.ParseIP(node.Address)
+		if ip == nil {
+			return fmt.Errorf("dns registry requires an ip address for node %s, got %s", node.Id, node.Address)
+		}
+
+		target := nodeName(node.Id, d.domain)
+
+		// replace any records previously registered for the node
+		old, err := d.targets(s.Name, target)
+		if err != nil {
+			return err
+		}
+		if len(old) > 0 {
+			m.Remove(old)
+		}
+		m.RemoveName([]dns.RR{&dns.ANY{Hdr: dns.RR_Header{Name: target}}})
+
+		rrs = append(rrs, &dns.SRV{
+			Hdr:    header(name, dns.TypeSRV, ttl),
+			Port:   uint16(node.Port),
+			Target: target,
+		})
+
+		if ip4 := ip.To4(); ip4 != nil {
+			rrs = append(rrs, &dns.A{Hdr: header(target, dns.TypeA, ttl), A: ip4})
+		} else {
+			rrs = append(rrs, &dns.AAAA{Hdr: header(target, dns.TypeAAAA, ttl), AAAA: ip})
+		}
+
+		rrs = append(rrs, &dns.TXT{
+			Hdr: header(target, dns.TypeTXT, ttl),
+			Txt: encodeTXT(s.Version, node),
+		})
+	}
+
+	m.Insert(rrs)
+
+	return d.sendUpdate(m)
+}
+
+func (d *dnsRegistry) Deregister(s *registry.Service) error {
+	if !d.update {
+		return ErrReadOnly
+	}
+
+	if len(s.Nodes) == 0 {
+		return errors.New("Require at least one node")
+	}
+
+	srvs, err := d.srvs(s.Name)
+	if err != nil {
+		return err
+	}
+
+	m := new(dns.Msg)
+	m.SetUpdate(dns.Fqdn(d.domain))
+
+	targets := make(map[string]bool)
+	for _, node := range s.Nodes {
+		target := nodeName(node.Id, d.domain)
+		targets[strings.ToLower(target)] = true
+		m.RemoveName([]dns.RR{&dns.ANY{Hdr: dns.RR_Header{Name: target}}})
+	}
+
+	var old []dns.RR
+	for _, srv := range srvs {
+		if targets[strings.ToLower(srv.Target)] {
+			old = append(old, srv)
+		}
+	}
+	if len(old) > 0 {
+		m.Remove(old)
+	}
+
+	// stop listing the service once its last node is gone
+	if len(old) == len(srvs) {
+		m.Remove([]dns.RR{&dns.PTR{
+			Hdr: header(browseName(d.domain), dns.TypePTR, 0),
+			Ptr: serviceName(s.Name, d.domain),
+		}})
+	}
+
+	return d.sendUpdate(m)
+}
+
+func (d *dnsRegistry) GetService(name string) ([]*registry.Service, error) {
+	rsp, err := d.query(serviceName(name, d.domain), dns.TypeSRV)
+	if err != nil {
+		return nil, err
+	}
+
+	// addresses and metadata may already be in the additional section
+	addrs := make(map[string]string)
+	txts := make(map[string][]string)
+	for _, rr := range rsp.Extra {
+		switch r := rr.(type) {
+		case *dns.A:
+			addrs[strings.ToLower(r.Hdr.Name)] = r.A.String()
+		case *dns.AAAA:
+			addrs[strings.ToLower(r.Hdr.Name)] = r.AAAA.String()
+		case *dns.TXT:
+			txts[strings.ToLower(r.Hdr.Name)] = r.Txt
+		}
+	}
+
+	serviceMap := make(map[string]*registry.Service)
+
+	for _, rr := range rsp.Answer {
+		srv, ok := rr.(*dns.SRV)
+		if !ok {
+			continue
+		}
+
+		target := strings.ToLower(srv.Target)
+
+		node := &registry.Node{
+			Id:      strings.TrimSuffix(srv.Target, "."),
+			Address: strings.TrimSuffix(srv.Target, "."),
+			Port:    int(srv.Port),
+		}
+
+		if addr, ok := addrs[target]; ok {
+			node.Address = addr
+		} else if r, err := d.query(srv.Target, dns.TypeA); err == nil {
+			for _, a := range r.Answer {
+				if a, ok := a.(*dns.A); ok {
+					node.Address = a.A.String()
+					break
+				}
+			}
+		}
+
+		txt, ok := txts[target]
+		if !ok {
+			if r, err := d.query(srv.Target, dns.TypeTXT); err == nil {
+				for _, t := range r.Answer {
+					if t, ok := t.(*dns.TXT); ok {
+						txt = append(txt, t.Txt...)
+					}
+				}
+			}
+		}
+
+		version := decodeTXT(txt, node)
+
+		s, ok := serviceMap[version]
+		if !ok {
+			s = &registry.Service{
+				Name:    name,
+				Version: version,
+			}
+			serviceMap[version] = s
+		}
+		s.Nodes = append(s.Nodes, node)
+	}
+
+	if len(serviceMap) == 0 {
+		return nil, registry.ErrNotFound
+	}
+
+	d.Lock()
+	d.names[name] = true
+	d.Unlock()
+
+	var services []*registry.Service
+	for _, service := range serviceMap {
+		// servers may rotate answers, order nodes so watchers see no change
+		sort.Slice(service.Nodes, func(i, j int) bool { return service.Nodes[i].Id < service.Nodes[j].Id })
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+func (d *dnsRegistry) ListServices() ([]*registry.Service, error) {
+	rsp, err := d.query(browseName(d.domain), dns.TypePTR)
+	if err == registry.ErrNotFound {
+		return []*registry.Service{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	suffix := "._tcp." + dns.Fqdn(d.domain)
+	nameSet := make(map[string]bool)
+
+	for _, rr := range rsp.Answer {
+		ptr, ok := rr.(*dns.PTR)
+		if !ok || !strings.HasPrefix(ptr.Ptr, "_") || !strings.HasSuffix(ptr.Ptr, suffix) {
+			continue
+		}
+		nameSet[strings.TrimSuffix(strings.TrimPrefix(ptr.Ptr, "_"), suffix)] = true
+	}
+
+	var services []*registry.Service
+	for name := range nameSet {
+		services = append(services, &registry.Service{Name: name})
+	}
+	return services, nil
+}
+
+func (d *dnsRegistry) Watch() (registry.Watcher, error) {
+	return newWatcher(d), nil
+}
+
+func (d *dnsRegistry) String() string {
+	return "dns"
+}
+
+func NewRegistry(opts ...registry.Option) registry.Registry {
+	options := registry.Options{
+		Timeout: time.Second * 2,
+		Context: context.Background(),
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	var servers []string
+	for _, addr := range options.Addrs {
+		if len(addr) == 0 {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			addr = net.JoinHostPort(addr, "53")
+		}
+		servers = append(servers, addr)
+	}
+
+	// fall back to the system resolvers
+	if len(servers) == 0 {
+		if c, err := dns.ClientConfigFromFile("/etc/resolv.conf"); err == nil {
+			for _, s := range c.Servers {
+				servers = append(servers, net.JoinHostPort(s, c.Port))
+			}
+		}
+	}
+
+	if len(servers) == 0 {
+		servers = []string{"127.0.0.1:53"}
+	}
+
+	domain := DefaultDomain
+	if d, ok := options.Context.Value(domainKey{}).(string); ok {
+		domain = d
+	}
+
+	interval := DefaultInterval
+	if i, ok := options.Context.Value(intervalKey{}).(time.Duration); ok && i > 0 {
+		interval = i
+	}
+
+	update, _ := options.Context.Value(updateKey{}).(bool)
+
+	client := &dns.Client{
+		Net:     "udp",
+		Timeout: options.Timeout,
+	}
+
+	t, _ := options.Context.Value(tsigKey{}).(*tsig)
+	if t != nil {
+		client.TsigSecret = map[string]string{t.name: t.secret}
+	}
+
+	return &dnsRegistry{
+		opts:     options,
+		client:   client,
+		servers:  servers,
+		domain:   strings.TrimSuffix(domain, "."),
+		interval: interval,
+		update:   update,
+		tsig:     t,
+		names:    make(map[string]bool),
+	}
+}
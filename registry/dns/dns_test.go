@@ -0,0 +1,372 @@
+package dns
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/miekg/dns"
+)
+
+// zone is a minimal authoritative server supporting queries and updates
+type zone struct {
+	sync.Mutex
+	records []dns.RR
+}
+
+func equal(a, b dns.RR) bool {
+	ha, hb := *a.Header(), *b.Header()
+	ha.Class, ha.Ttl, ha.Rdlength = 0, 0, 0
+	hb.Class, hb.Ttl, hb.Rdlength = 0, 0, 0
+	return strings.EqualFold(ha.Name, hb.Name) && ha.Rrtype == hb.Rrtype &&
+		strings.TrimPrefix(a.String(), a.Header().String()) == strings.TrimPrefix(b.String(), b.Header().String())
+}
+
+func (z *zone) remove(fn func(dns.RR) bool) {
+	var records []dns.RR
+	for _, rr := range z.records {
+		if !fn(rr) {
+			records = append(records, rr)
+		}
+	}
+	z.records = records
+}
+
+func (z *zone) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	z.Lock()
+	defer z.Unlock()
+
+	m := new(dns.Msg)
+	m.SetReply(r)
+
+	if r.Opcode == dns.OpcodeUpdate {
+		for _, u := range r.Ns {
+			h := u.Header()
+			switch h.Class {
+			case dns.ClassANY:
+				z.remove(func(rr dns.RR) bool {
+					return strings.EqualFold(rr.Header().Name, h.Name) && (h.Rrtype == dns.TypeANY || h.Rrtype == rr.Header().Rrtype)
+				})
+			case dns.ClassNONE:
+				z.remove(func(rr dns.RR) bool {
+					return equal(rr, u)
+				})
+			default:
+				z.remove(func(rr dns.RR) bool {
+					return equal(rr, u)
+				})
+				z.records = append(z.records, dns.Copy(u))
+			}
+		}
+		w.WriteMsg(m)
+		return
+	}
+
+	var exists bool
+	for _, rr := range z.records {
+		if !strings.EqualFold(rr.Header().Name, r.Question[0].Name) {
+			continue
+		}
+		exists = true
+		if rr.Header().Rrtype == r.Question[0].Qtype {
+			m.Answer = append(m.Answer, dns.Copy(rr))
+		}
+	}
+
+	if !exists {
+		m.Rcode = dns.RcodeNameError
+	}
+
+	w.WriteMsg(m)
+}
+
+func newZone(t *testing.T, records ...string) (*zone, string, func()) {
+	z := &zone{}
+	for _, record := range records {
+		rr, err := dns.NewRR(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		z.records = append(z.records, rr)
+	}
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan bool)
+	srv := &dns.Server{
+		PacketConn:        pc,
+		Handler:           z,
+		NotifyStartedFunc: func() { close(started) },
+	}
+
+	go srv.ActivateAndServe()
+	<-started
+
+	return z, pc.LocalAddr().String(), func() { srv.Shutdown() }
+}
+
+var testRecords = []string{
+	"_services._dns-sd._udp.micro.local. 60 IN PTR _foo._tcp.micro.local.",
+	"_services._dns-sd._udp.micro.local. 60 IN PTR _bar._tcp.micro.local.",
+	"_foo._tcp.micro.local. 60 IN SRV 0 0 8080 foo-1.micro.local.",
+	"_foo._tcp.micro.local. 60 IN SRV 0 0 8081 foo-2.micro.local.",
+	"foo-1.micro.local. 60 IN A 10.0.0.1",
+	"foo-1.micro.local. 60 IN TXT \"id=foo-1\" \"version=1.0.0\" \"zone=a\"",
+	"foo-2.micro.local. 60 IN A 10.0.0.2",
+	"foo-2.micro.local. 60 IN TXT \"id=foo-2\" \"version=1.0.1\"",
+}
+
+func TestGetService(t *testing.T) {
+	_, addr, stop := newZone(t, testRecords...)
+	defer stop()
+
+	r := NewRegistry(registry.Addrs(addr), Domain("micro.local"))
+
+	services, err := r.GetService("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(services) != 2 {
+		t.Fatalf("Expected 2 versions of foo, got %d", len(services))
+	}
+
+	for _, s := range services {
+		if len(s.Nodes) != 1 {
+			t.Fatalf("Expected 1 node for version %s, got %d", s.Version, len(s.Nodes))
+		}
+		node := s.Nodes[0]
+
+		switch s.Version {
+		case "1.0.0":
+			if node.Id != "foo-1" || node.Address != "10.0.0.1" || node.Port != 8080 {
+				t.Fatalf("Unexpected node %+v", node)
+			}
+			if node.Metadata["zone"] != "a" {
+				t.Fatalf("Expected zone metadata a, got %+v", node.Metadata)
+			}
+		case "1.0.1":
+			if node.Id != "foo-2" || node.Address != "10.0.0.2" || node.Port != 8081 {
+				t.Fatalf("Unexpected node %+v", node)
+			}
+		default:
+			t.Fatalf("Unexpected version %s", s.Version)
+		}
+	}
+
+	if _, err := r.GetService("baz"); err != registry.ErrNotFound {
+		t.Fatalf("Expected %v, got %v", registry.ErrNotFound, err)
+	}
+
+	services, err = r.ListServices()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(services) != 2 {
+		t.Fatalf("Expected 2 services, got %+v", services)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	r := NewRegistry(registry.Addrs("127.0.0.1:53"))
+
+	service := &registry.Service{
+		Name:  "foo",
+		Nodes: []*registry.Node{{Id: "foo-1", Address: "10.0.0.1", Port: 8080}},
+	}
+
+	if err := r.Register(service); err != ErrReadOnly {
+		t.Fatalf("Expected %v, got %v", ErrReadOnly, err)
+	}
+
+	if err := r.Deregister(service); err != ErrReadOnly {
+		t.Fatalf("Expected %v, got %v", ErrReadOnly, err)
+	}
+}
+
+func TestDynamicUpdates(t *testing.T) {
+	_, addr, stop := newZone(t)
+	defer stop()
+
+	r := NewRegistry(registry.Addrs(addr), Domain("micro.local"), DynamicUpdates())
+
+	service := &registry.Service{
+		Name:    "go.micro.srv.foo",
+		Version: "1.0.0",
+		Nodes: []*registry.Node{
+			{
+				Id:       "go.micro.srv.foo-1",
+				Address:  "10.0.0.1",
+				Port:     8080,
+				Metadata: map[string]string{"zone": "a"},
+			},
+		},
+	}
+
+	if err := r.Register(service, registry.RegisterTTL(time.Second*30)); err != nil {
+		t.Fatal(err)
+	}
+
+	// re-register on a new port
+	service.Nodes[0].Port = 9090
+	if err := r.Register(service); err != nil {
+		t.Fatal(err)
+	}
+
+	services, err := r.GetService(service.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(services) != 1 || len(services[0].Nodes) != 1 {
+		t.Fatalf("Expected 1 service with 1 node, got %+v", services)
+	}
+
+	node := services[0].Nodes[0]
+	if node.Id != "go.micro.srv.foo-1" || node.Address != "10.0.0.1" || node.Port != 9090 || node.Metadata["zone"] != "a" {
+		t.Fatalf("Unexpected node %+v", node)
+	}
+
+	if services[0].Version != "1.0.0" {
+		t.Fatalf("Expected version 1.0.0, got %s", services[0].Version)
+	}
+
+	list, err := r.ListServices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Name != service.Name {
+		t.Fatalf("Expected service %s to be listed, got %+v", service.Name, list)
+	}
+
+	if err := r.Deregister(service); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.GetService(service.Name); err != registry.ErrNotFound {
+		t.Fatalf("Expected %v, got %v", registry.ErrNotFound, err)
+	}
+
+	list, err = r.ListServices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("Expected no services to be listed, got %+v", list)
+	}
+}
+
+func TestDeregisterNode(t *testing.T) {
+	_, addr, stop := newZone(t)
+	defer stop()
+
+	r := NewRegistry(registry.Addrs(addr), Domain("micro.local"), DynamicUpdates())
+
+	service := &registry.Service{
+		Name:    "foo",
+		Version: "1.0.0",
+		Nodes: []*registry.Node{
+			{Id: "foo-2", Address: "10.0.0.2", Port: 8080},
+			{Id: "foo-1", Address: "10.0.0.1", Port: 8080},
+		},
+	}
+
+	if err := r.Register(service); err != nil {
+		t.Fatal(err)
+	}
+
+	services, err := r.GetService("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nodes are ordered by id
+	if len(services) != 1 || len(services[0].Nodes) != 2 || services[0].Nodes[0].Id != "foo-1" {
+		t.Fatalf("Expected nodes foo-1 and foo-2, got %+v", services)
+	}
+
+	// the service is listed while it has nodes
+	if err := r.Deregister(&registry.Service{Name: "foo", Nodes: service.Nodes[:1]}); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := r.ListServices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("Expected foo to be listed, got %+v", list)
+	}
+
+	if err := r.Deregister(&registry.Service{Name: "foo", Nodes: service.Nodes[1:]}); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err = r.ListServices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("Expected no services to be listed, got %+v", list)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	_, addr, stop := newZone(t, testRecords...)
+	defer stop()
+
+	r := NewRegistry(
+		registry.Addrs(addr),
+		Domain("micro.local"),
+		Interval(time.Millisecond*10),
+		DynamicUpdates(),
+	)
+
+	w, err := r.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	// let the watcher take its initial snapshot
+	time.Sleep(time.Millisecond * 50)
+
+	service := &registry.Service{
+		Name:    "bar",
+		Version: "latest",
+		Nodes:   []*registry.Node{{Id: "bar-1", Address: "10.0.0.3", Port: 8080}},
+	}
+
+	if err := r.Register(service); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Action != "create" || res.Service.Name != "bar" {
+		t.Fatalf("Expected create of bar, got %s of %s", res.Action, res.Service.Name)
+	}
+
+	if err := r.Deregister(service); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err = w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Action != "delete" || res.Service.Name != "bar" {
+		t.Fatalf("Expected delete of bar, got %s of %s", res.Action, res.Service.Name)
+	}
+}
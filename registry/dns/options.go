@@ -0,0 +1,60 @@
+package dns
+
+import (
+	"strings"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+)
+
+type domainKey struct{}
+type intervalKey struct{}
+type updateKey struct{}
+type tsigKey struct{}
+
+// Domain sets the domain services are resolved in e.g. service.consul
+func Domain(d string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, domainKey{}, d)
+	}
+}
+
+// Interval sets how often watchers poll dns for changes
+func Interval(d time.Duration) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, intervalKey{}, d)
+	}
+}
+
+// DynamicUpdates enables Register and Deregister using RFC 2136 dynamic
+// updates of the zone named by the domain
+func DynamicUpdates() registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, updateKey{}, true)
+	}
+}
+
+// TSIG signs requests with the named key and its base64 encoded secret
+// using HMAC-SHA256. It's usually required for dynamic updates.
+func TSIG(name, secret string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, tsigKey{}, &tsig{
+			name:   dns.Fqdn(strings.ToLower(name)),
+			secret: secret,
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package dns
+
+import (
+	"errors"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	hash "github.com/mitchellh/hashstructure"
+)
+
+type dnsWatcher struct {
+	r    *dnsRegistry
+	next chan *registry.Result
+	exit chan bool
+}
+
+func newWatcher(r *dnsRegistry) registry.Watcher {
+	w := &dnsWatcher{
+		r:    r,
+		next: make(chan *registry.Result, 10),
+		exit: make(chan bool),
+	}
+	go w.run()
+	return w
+}
+
+func findVersion(services []*registry.Service, version string) *registry.Service {
+	for _, s := range services {
+		if s.Version == version {
+			return s
+		}
+	}
+	return nil
+}
+
+func delNodes(old, del []*registry.Node) []*registry.Node {
+	var nodes []*registry.Node
+	for _, o := range old {
+		var rem bool
+		for _, n := range del {
+			if o.Id == n.Id {
+				rem = true
+				break
+			}
+		}
+		if !rem {
+			nodes = append(nodes, o)
+		}
+	}
+	return nodes
+}
+
+// diff returns the results required to get from the old services to the new
+func diff(old, neu map[string][]*registry.Service) []*registry.Result {
+	var results []*registry.Result
+
+	for name, services := range neu {
+		for _, s := range services {
+			o := findVersion(old[name], s.Version)
+			if o == nil {
+				results = append(results, &registry.Result{Action: "create", Service: s})
+				continue
+			}
+
+			h1, err1 := hash.Hash(o, nil)
+			h2, err2 := hash.Hash(s, nil)
+			if err1 == nil && err2 == nil && h1 == h2 {
+				continue
+			}
+
+			results = append(results, &registry.Result{Action: "update", Service: s})
+
+			if nodes := delNodes(o.Nodes, s.Nodes); len(nodes) > 0 {
+				service := new(registry.Service)
+				*service = *o
+				service.Nodes = nodes
+				results = append(results, &registry.Result{Action: "delete", Service: service})
+			}
+		}
+	}
+
+	for name, services := range old {
+		for _, s := range services {
+			if findVersion(neu[name], s.Version) == nil {
+				results = append(results, &registry.Result{Action: "delete", Service: s})
+			}
+		}
+	}
+
+	return results
+}
+
+// poll resolves every known service. Services which are not found are
+// returned empty, lookups which fail are left out of the result.
+func (w *dnsWatcher) poll() map[string][]*registry.Service {
+	names := make(map[string]bool)
+
+	if services, err := w.r.ListServices(); err == nil {
+		for _, s := range services {
+			names[s.Name] = true
+		}
+	}
+
+	w.r.RLock()
+	for name := range w.r.names {
+		names[name] = true
+	}
+	w.r.RUnlock()
+
+	services := make(map[string][]*registry.Service)
+	for name := range names {
+		s, err := w.r.GetService(name)
+		if err == registry.ErrNotFound {
+			services[name] = nil
+			continue
+		} else if err != nil {
+			continue
+		}
+		services[name] = s
+	}
+	return services
+}
+
+func (w *dnsWatcher) run() {
+	t := time.NewTicker(w.r.interval)
+	defer t.Stop()
+
+	services := w.poll()
+
+	for {
+		select {
+		case <-w.exit:
+			return
+		case <-t.C:
+			neu := w.poll()
+
+			// keep the last known state of services which failed to resolve
+			for name, s := range services {
+				if _, ok := neu[name]; !ok {
+					neu[name] = s
+				}
+			}
+
+			for _, r := range diff(services, neu) {
+				select {
+				case w.next <- r:
+				case <-w.exit:
+					return
+				}
+			}
+
+			services = neu
+		}
+	}
+}
+
+func (w *dnsWatcher) Next() (*registry.Result, error) {
+	select {
+	case r := <-w.next:
+		return r, nil
+	case <-w.exit:
+		return nil, errors.New("watcher stopped")
+	}
+}
+
+func (w *dnsWatcher) Stop() {
+	select {
+	case <-w.exit:
+		return
+	default:
+		close(w.exit)
+	}
+}
@@ -16,7 +16,6 @@ import (
 	"github.com/micro/go-micro/cmd"
 	"github.com/micro/go-micro/registry"
 
-	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
 	hash "github.com/mitchellh/hashstructure"
 )
 
@@ -24,12 +23,25 @@ var (
 	prefix = "/micro-registry"
 )
 
+// client is the part of the etcd client used by the registry
+type client interface {
+	Grant(ctx context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error)
+	Revoke(ctx context.Context, id clientv3.LeaseID) (*clientv3.LeaseRevokeResponse, error)
+	KeepAlive(ctx context.Context, id clientv3.LeaseID) (<-chan *clientv3.LeaseKeepAliveResponse, error)
+	Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error)
+	Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error)
+	Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error)
+	Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan
+}
+
 type etcdv3Registry struct {
-	client  *clientv3.Client
+	client  client
 	options registry.Options
 	sync.Mutex
-	register map[string]uint64
-	leases   map[string]clientv3.LeaseID
+	// registrations mapped by node path
+	registrations map[string]*registration
+	// health is called when the health of a registration changes
+	health HealthFunc
 }
 
 func init() {
@@ -62,21 +74,31 @@ func (e *etcdv3Registry) Deregister(s *registry.Service) error {
 		return errors.New("Require at least one node")
 	}
 
-	e.Lock()
-	// delete our hash of the service
-	delete(e.register, s.Name)
-	// delete our lease of the service
-	delete(e.leases, s.Name)
-	e.Unlock()
-
 	ctx, cancel := context.WithTimeout(context.Background(), e.options.Timeout)
 	defer cancel()
 
 	for _, node := range s.Nodes {
-		_, err := e.client.Delete(ctx, nodePath(s.Name, node.Id))
+		key := nodePath(s.Name, node.Id)
+
+		e.Lock()
+		// stop keeping the lease alive and forget the node
+		r, ok := e.registrations[key]
+		if ok {
+			r.stop()
+			delete(e.registrations, key)
+		}
+		e.Unlock()
+
+		_, err := e.client.Delete(ctx, key)
 		if err != nil {
 			return err
 		}
+
+		if ok {
+			if lease := r.leaseID(); lease != clientv3.NoLease {
+				e.client.Revoke(ctx, lease)
+			}
+		}
 	}
 	return nil
 }
@@ -86,79 +108,56 @@ func (e *etcdv3Registry) Register(s *registry.Service, opts ...registry.Register
 		return errors.New("Require at least one node")
 	}
 
-	var leaseNotFound bool
-	//refreshing lease if existing
-	leaseID, ok := e.leases[s.Name]
-	if ok {
-		if _, err := e.client.KeepAliveOnce(context.TODO(), leaseID); err != nil {
-			if err != rpctypes.ErrLeaseNotFound {
-				return err
-			}
-
-			// lease not found do register
-			leaseNotFound = true
-		}
-	}
-
-	// create hash of service; uint64
-	h, err := hash.Hash(s, nil)
-	if err != nil {
-		return err
-	}
-
-	// get existing hash
-	e.Lock()
-	v, ok := e.register[s.Name]
-	e.Unlock()
-
-	// the service is unchanged, skip registering
-	if ok && v == h && !leaseNotFound {
-		return nil
-	}
-
-	service := &registry.Service{
-		Name:      s.Name,
-		Version:   s.Version,
-		Metadata:  s.Metadata,
-		Endpoints: s.Endpoints,
-	}
-
 	var options registry.RegisterOptions
 	for _, o := range opts {
 		o(&options)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), e.options.Timeout)
-	defer cancel()
+	for _, node := range s.Nodes {
+		service := &registry.Service{
+			Name:      s.Name,
+			Version:   s.Version,
+			Metadata:  s.Metadata,
+			Endpoints: s.Endpoints,
+			Nodes:     []*registry.Node{node},
+		}
 
-	var lgr *clientv3.LeaseGrantResponse
-	if options.TTL.Seconds() > 0 {
-		lgr, err = e.client.Grant(ctx, int64(options.TTL.Seconds()))
+		// create hash of the node's service; uint64
+		h, err := hash.Hash(service, nil)
 		if err != nil {
 			return err
 		}
-	}
 
-	for _, node := range s.Nodes {
-		service.Nodes = []*registry.Node{node}
-		if lgr != nil {
-			_, err = e.client.Put(ctx, nodePath(service.Name, node.Id), encode(service), clientv3.WithLease(lgr.ID))
-		} else {
-			_, err = e.client.Put(ctx, nodePath(service.Name, node.Id), encode(service))
+		key := nodePath(s.Name, node.Id)
+
+		// get the existing registration
+		e.Lock()
+		r, ok := e.registrations[key]
+		e.Unlock()
+
+		// the node is unchanged and its lease is kept alive, skip registering
+		if ok && r.hash == h && r.ttl == options.TTL && r.healthy() {
+			continue
 		}
-		if err != nil {
+
+		nr := newRegistration(key, service, h, options.TTL)
+		if err := e.put(nr); err != nil {
+			nr.stop()
 			return err
 		}
-	}
 
-	e.Lock()
-	// save our hash of the service
-	e.register[s.Name] = h
-	// save our leaseID of the service
-	if lgr != nil {
-		e.leases[s.Name] = lgr.ID
+		e.Lock()
+		// replace any previous registration of the node
+		if ok {
+			r.stop()
+		}
+		e.registrations[key] = nr
+		e.Unlock()
+
+		if nr.leaseID() != clientv3.NoLease {
+			go e.keepAlive(nr)
+		}
 	}
-	e.Unlock()
 
 	return nil
 }
@@ -281,12 +280,17 @@ func NewRegistry(opts ...registry.Option) registry.Registry {
 		config.Endpoints = cAddrs
 	}
 
+	var health HealthFunc
+	if options.Context != nil {
+		health, _ = options.Context.Value(healthKey{}).(HealthFunc)
+	}
+
 	cli, _ := clientv3.New(config)
 	e := &etcdv3Registry{
-		client:   cli,
-		options:  options,
-		register: make(map[string]uint64),
-		leases:   make(map[string]clientv3.LeaseID),
+		client:        cli,
+		options:       options,
+		registrations: make(map[string]*registration),
+		health:        health,
 	}
 
 	return e
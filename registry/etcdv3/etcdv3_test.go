@@ -0,0 +1,204 @@
+package etcdv3
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/micro/go-micro/registry"
+	"golang.org/x/net/context"
+)
+
+// fakeClient is an etcd client whose leases can be lost
+type fakeClient struct {
+	sync.Mutex
+	lease   clientv3.LeaseID
+	kvs     map[string]clientv3.LeaseID
+	lost    map[clientv3.LeaseID]chan bool
+	revoked []clientv3.LeaseID
+	failPut bool
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		kvs:  make(map[string]clientv3.LeaseID),
+		lost: make(map[clientv3.LeaseID]chan bool),
+	}
+}
+
+func (f *fakeClient) Grant(ctx context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.lease++
+	f.lost[f.lease] = make(chan bool)
+	return &clientv3.LeaseGrantResponse{ID: f.lease}, nil
+}
+
+func (f *fakeClient) Revoke(ctx context.Context, id clientv3.LeaseID) (*clientv3.LeaseRevokeResponse, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.revoked = append(f.revoked, id)
+	return &clientv3.LeaseRevokeResponse{}, nil
+}
+
+func (f *fakeClient) KeepAlive(ctx context.Context, id clientv3.LeaseID) (<-chan *clientv3.LeaseKeepAliveResponse, error) {
+	f.Lock()
+	lost, ok := f.lost[id]
+	f.Unlock()
+
+	if !ok {
+		return nil, errors.New("lease not found")
+	}
+
+	ch := make(chan *clientv3.LeaseKeepAliveResponse)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-lost:
+		}
+		close(ch)
+	}()
+	return ch, nil
+}
+
+func (f *fakeClient) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	f.Lock()
+	defer f.Unlock()
+	if f.failPut {
+		return nil, errors.New("put failed")
+	}
+	// the lease is always the latest granted in these tests
+	f.kvs[key] = f.lease
+	return &clientv3.PutResponse{}, nil
+}
+
+func (f *fakeClient) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	return &clientv3.GetResponse{}, nil
+}
+
+func (f *fakeClient) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	f.Lock()
+	defer f.Unlock()
+	delete(f.kvs, key)
+	return &clientv3.DeleteResponse{}, nil
+}
+
+func (f *fakeClient) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	return nil
+}
+
+// expire loses the lease and the keys attached to it
+func (f *fakeClient) expire(id clientv3.LeaseID) {
+	f.Lock()
+	defer f.Unlock()
+	for k, l := range f.kvs {
+		if l == id {
+			delete(f.kvs, k)
+		}
+	}
+	close(f.lost[id])
+}
+
+func (f *fakeClient) leaseOf(key string) (clientv3.LeaseID, bool) {
+	f.Lock()
+	defer f.Unlock()
+	l, ok := f.kvs[key]
+	return l, ok
+}
+
+func TestLeaseLost(t *testing.T) {
+	fc := newFakeClient()
+	health := make(chan error, 10)
+
+	e := &etcdv3Registry{
+		client:        fc,
+		options:       registry.Options{Timeout: time.Second},
+		registrations: make(map[string]*registration),
+		health: func(s *registry.Service, err error) {
+			health <- err
+		},
+	}
+
+	service := &registry.Service{
+		Name:    "foo",
+		Version: "1.0.0",
+		Nodes:   []*registry.Node{{Id: "foo-1", Address: "localhost", Port: 8080}},
+	}
+	key := nodePath(service.Name, "foo-1")
+
+	if err := e.Register(service, registry.RegisterTTL(time.Second*10)); err != nil {
+		t.Fatal(err)
+	}
+
+	if l, ok := fc.leaseOf(key); !ok || l != 1 {
+		t.Fatalf("Expected node registered with lease 1, got %v %v", l, ok)
+	}
+
+	fc.expire(1)
+
+	for _, expected := range []error{ErrLeaseLost, nil} {
+		select {
+		case err := <-health:
+			if err != expected {
+				t.Fatalf("Expected health %v, got %v", expected, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for health %v", expected)
+		}
+	}
+
+	// registered again with a new lease
+	if l, ok := fc.leaseOf(key); !ok || l != 2 {
+		t.Fatalf("Expected node registered again with lease 2, got %v %v", l, ok)
+	}
+
+	// the healthy registration is skipped
+	if err := e.Register(service, registry.RegisterTTL(time.Second*10)); err != nil {
+		t.Fatal(err)
+	}
+	if l, _ := fc.leaseOf(key); l != 2 {
+		t.Fatalf("Expected registration to be skipped, got lease %v", l)
+	}
+
+	if err := e.Deregister(service); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := fc.leaseOf(key); ok {
+		t.Fatal("Expected node to be deregistered")
+	}
+
+	fc.Lock()
+	revoked := fc.revoked
+	fc.Unlock()
+
+	if len(revoked) != 1 || revoked[0] != 2 {
+		t.Fatalf("Expected lease 2 to be revoked, got %v", revoked)
+	}
+}
+
+func TestRegisterFailure(t *testing.T) {
+	fc := newFakeClient()
+	fc.failPut = true
+
+	e := &etcdv3Registry{
+		client:        fc,
+		options:       registry.Options{Timeout: time.Second},
+		registrations: make(map[string]*registration),
+	}
+
+	service := &registry.Service{
+		Name:  "foo",
+		Nodes: []*registry.Node{{Id: "foo-1", Address: "localhost", Port: 8080}},
+	}
+
+	if err := e.Register(service, registry.RegisterTTL(time.Second*10)); err == nil {
+		t.Fatal("Expected register to fail")
+	}
+
+	if len(e.registrations) != 0 {
+		t.Fatalf("Expected no registrations, got %+v", e.registrations)
+	}
+}
@@ -0,0 +1,166 @@
+package etcdv3
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-plugins/internal/backoff"
+	"golang.org/x/net/context"
+)
+
+/*
+	Every node registered with a TTL is given its own lease which is kept
+	alive in the background for as long as the node is registered. If the
+	lease is lost, for example because etcd was unreachable for longer than
+	the TTL, the node is registered again with a new lease until it succeeds
+	or the node is deregistered.
+*/
+
+var (
+	// ErrLeaseLost is reported to the health hook when the lease of a node is lost
+	ErrLeaseLost = errors.New("lease lost")
+
+	// maximum time to wait between registration attempts
+	maxBackoff = time.Second * 30
+)
+
+// registration is a node registered by this registry
+type registration struct {
+	key     string
+	service *registry.Service
+	hash    uint64
+	ttl     time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	sync.RWMutex
+	lease clientv3.LeaseID
+	ok    bool
+}
+
+func newRegistration(key string, s *registry.Service, h uint64, ttl time.Duration) *registration {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &registration{
+		key:     key,
+		service: s,
+		hash:    h,
+		ttl:     ttl,
+		ctx:     ctx,
+		cancel:  cancel,
+		lease:   clientv3.NoLease,
+	}
+}
+
+func (r *registration) leaseID() clientv3.LeaseID {
+	r.RLock()
+	defer r.RUnlock()
+	return r.lease
+}
+
+// healthy returns true if the node is registered
+func (r *registration) healthy() bool {
+	r.RLock()
+	defer r.RUnlock()
+	return r.ok
+}
+
+func (r *registration) setHealthy(ok bool) {
+	r.Lock()
+	r.ok = ok
+	r.Unlock()
+}
+
+// stop keeping the registration alive
+func (r *registration) stop() {
+	r.cancel()
+}
+
+// notify calls the health hook if set
+func (e *etcdv3Registry) notify(s *registry.Service, err error) {
+	if e.health != nil {
+		e.health(s, err)
+	}
+}
+
+// put writes the node, with a new lease if it has a TTL
+func (e *etcdv3Registry) put(r *registration) error {
+	ctx, cancel := context.WithTimeout(r.ctx, e.options.Timeout)
+	defer cancel()
+
+	lease := clientv3.NoLease
+	if r.ttl.Seconds() > 0 {
+		lgr, err := e.client.Grant(ctx, int64(r.ttl.Seconds()))
+		if err != nil {
+			return err
+		}
+		lease = lgr.ID
+	}
+
+	var err error
+	if lease != clientv3.NoLease {
+		_, err = e.client.Put(ctx, r.key, encode(r.service), clientv3.WithLease(lease))
+	} else {
+		_, err = e.client.Put(ctx, r.key, encode(r.service))
+	}
+	if err != nil {
+		return err
+	}
+
+	r.Lock()
+	r.lease = lease
+	r.ok = true
+	r.Unlock()
+
+	return nil
+}
+
+// keepAlive keeps the lease of a registration alive and
+// registers the node again if the lease is lost
+func (e *etcdv3Registry) keepAlive(r *registration) {
+	for {
+		ch, err := e.client.KeepAlive(r.ctx, r.leaseID())
+		if err == nil {
+			// the channel is closed once the lease is lost
+			// or the registration is stopped
+			for range ch {
+			}
+		}
+
+		if r.ctx.Err() != nil {
+			return
+		}
+
+		r.setHealthy(false)
+		e.notify(r.service, ErrLeaseLost)
+
+		// register again until it succeeds or the node is deregistered
+		for i := 1; ; i++ {
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-time.After(backoff.Exponential(i, maxBackoff)):
+			}
+
+			if err := e.put(r); err != nil {
+				e.notify(r.service, err)
+				continue
+			}
+
+			break
+		}
+
+		// deregistered while registering again
+		if r.ctx.Err() != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), e.options.Timeout)
+			e.client.Revoke(ctx, r.leaseID())
+			cancel()
+			return
+		}
+
+		e.notify(r.service, nil)
+	}
+}
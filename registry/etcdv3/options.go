@@ -0,0 +1,23 @@
+package etcdv3
+
+import (
+	"github.com/micro/go-micro/registry"
+	"golang.org/x/net/context"
+)
+
+// HealthFunc is called with ErrLeaseLost when the lease of a registered node
+// is lost, with any error registering the node again, and with nil once the
+// node has been registered again.
+type HealthFunc func(s *registry.Service, err error)
+
+type healthKey struct{}
+
+// HealthHook sets a function which reports the health of registrations
+func HealthHook(fn HealthFunc) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, healthKey{}, fn)
+	}
+}
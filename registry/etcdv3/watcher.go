@@ -12,7 +12,7 @@ import (
 type etcdv3Watcher struct {
 	stop    chan bool
 	w       clientv3.WatchChan
-	client  *clientv3.Client
+	client  client
 	timeout time.Duration
 }
 
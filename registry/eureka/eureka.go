@@ -21,6 +21,7 @@ type fargoConnection interface {
 	RegisterInstance(*fargo.Instance) error
 	DeregisterInstance(*fargo.Instance) error
 	HeartBeatInstance(*fargo.Instance) error
+	UpdateInstanceStatus(*fargo.Instance, fargo.StatusType) error
 	GetInstance(string, string) (*fargo.Instance, error)
 	GetApp(string) (*fargo.Application, error)
 	GetApps() (map[string]*fargo.Application, error)
@@ -30,6 +31,8 @@ type fargoConnection interface {
 type eurekaRegistry struct {
 	conn fargoConnection
 	opts registry.Options
+	// statuses of the instances returned by GetService
+	statuses []fargo.StatusType
 }
 
 func init() {
@@ -62,26 +65,53 @@ func newRegistry(opts ...registry.Option) registry.Registry {
 		fargo.HttpClient = c
 	}
 
+	statuses := []fargo.StatusType{fargo.UP}
+	if s, ok := options.Context.Value(contextStatuses{}).([]fargo.StatusType); ok && len(s) > 0 {
+		statuses = s
+	}
+
 	conn := fargo.NewConn(cAddrs...)
 	conn.PollInterval = time.Second * 5
 
 	return &eurekaRegistry{
-		conn: &conn,
-		opts: options,
+		conn:     &conn,
+		opts:     options,
+		statuses: statuses,
 	}
 }
 
 func (e *eurekaRegistry) Register(s *registry.Service, opts ...registry.RegisterOption) error {
+	var options registry.RegisterOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
 	instance, err := serviceToInstance(s)
 	if err != nil {
 		return err
 	}
 
-	if e.instanceRegistered(instance) {
-		return e.conn.HeartBeatInstance(instance)
+	if options.Context != nil {
+		if status, ok := options.Context.Value(contextStatus{}).(fargo.StatusType); ok {
+			instance.Status = status
+		}
 	}
 
-	return e.conn.RegisterInstance(instance)
+	registered, err := e.conn.GetInstance(instance.App, instance.UniqueID(*instance))
+	if err != nil {
+		return e.conn.RegisterInstance(instance)
+	}
+
+	if err := e.conn.HeartBeatInstance(instance); err != nil {
+		return err
+	}
+
+	// heartbeats do not change the status
+	if registered != nil && registered.Status != instance.Status {
+		return e.conn.UpdateInstanceStatus(instance, instance.Status)
+	}
+
+	return nil
 }
 
 func (e *eurekaRegistry) Deregister(s *registry.Service) error {
@@ -97,7 +127,7 @@ func (e *eurekaRegistry) GetService(name string) ([]*registry.Service, error) {
 	if err != nil {
 		return nil, err
 	}
-	return appToService(app), nil
+	return filterStatus(appToService(app), e.statuses), nil
 }
 
 func (e *eurekaRegistry) ListServices() ([]*registry.Service, error) {
@@ -116,18 +146,13 @@ func (e *eurekaRegistry) ListServices() ([]*registry.Service, error) {
 }
 
 func (e *eurekaRegistry) Watch() (registry.Watcher, error) {
-	return newWatcher(e.conn), nil
+	return newWatcher(e.conn, e.statuses), nil
 }
 
 func (e *eurekaRegistry) String() string {
 	return "eureka"
 }
 
-func (e *eurekaRegistry) instanceRegistered(instance *fargo.Instance) bool {
-	_, err := e.conn.GetInstance(instance.App, instance.UniqueID(*instance))
-	return err == nil
-}
-
 func NewRegistry(opts ...registry.Option) registry.Registry {
 	return newRegistry(opts...)
 }
@@ -73,3 +73,78 @@ func TestSwitchHttpClient(t *testing.T) {
 		t.Errorf("Unexpected fargo.HttpClient: got %v, want %v", fargo.HttpClient, expected)
 	}
 }
+
+func TestRegisterStatus(t *testing.T) {
+	eureka := NewRegistry().(*eurekaRegistry)
+
+	service := &registry.Service{
+		Nodes: []*registry.Node{new(registry.Node)},
+	}
+
+	mockConn := new(mock.FargoConnection)
+	mockConn.GetInstanceReturns(&fargo.Instance{Status: fargo.UP}, nil)
+	eureka.conn = mockConn
+
+	// heartbeat with an unchanged status
+	if err := eureka.Register(service); err != nil {
+		t.Fatal("Unexpected Register error:", err)
+	}
+
+	if mockConn.UpdateInstanceStatusCallCount() != 0 {
+		t.Errorf("Expected no calls of UpdateInstanceStatus, got %d calls.", mockConn.UpdateInstanceStatusCallCount())
+	}
+
+	if err := eureka.Register(service, Status(fargo.OUTOFSERVICE)); err != nil {
+		t.Fatal("Unexpected Register error:", err)
+	}
+
+	if mockConn.UpdateInstanceStatusCallCount() != 1 {
+		t.Fatalf("Expected exactly 1 call of UpdateInstanceStatus, got %d calls.", mockConn.UpdateInstanceStatusCallCount())
+	}
+
+	if _, status := mockConn.UpdateInstanceStatusArgsForCall(0); status != fargo.OUTOFSERVICE {
+		t.Errorf("Unexpected status: want %v, got %v", fargo.OUTOFSERVICE, status)
+	}
+}
+
+func TestGetServiceStatuses(t *testing.T) {
+	instance := func(id string, status fargo.StatusType) *fargo.Instance {
+		i := &fargo.Instance{HostName: id, Status: status}
+		i.SetMetadataString("version", "1")
+		return i
+	}
+
+	app := &fargo.Application{
+		Name: "FOO",
+		Instances: []*fargo.Instance{
+			instance("up", fargo.UP),
+			instance("down", fargo.DOWN),
+			instance("starting", fargo.STARTING),
+		},
+	}
+
+	testData := []struct {
+		opts  []registry.Option
+		nodes int
+	}{
+		{nil, 1},
+		{[]registry.Option{Statuses(fargo.UP, fargo.STARTING)}, 2},
+	}
+
+	for _, test := range testData {
+		eureka := NewRegistry(test.opts...).(*eurekaRegistry)
+
+		mockConn := new(mock.FargoConnection)
+		mockConn.GetAppReturns(app, nil)
+		eureka.conn = mockConn
+
+		services, err := eureka.GetService("foo")
+		if err != nil {
+			t.Fatal("Unexpected GetService error:", err)
+		}
+
+		if len(services) != 1 || len(services[0].Nodes) != test.nodes {
+			t.Errorf("Expected 1 service with %d nodes, got %+v", test.nodes, services)
+		}
+	}
+}
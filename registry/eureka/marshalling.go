@@ -27,6 +27,7 @@ func appToService(app *fargo.Application) []*registry.Service {
 		if err != nil {
 			continue
 		}
+		version = k
 
 		k, err = instance.Metadata.GetString("endpoints")
 		if err == nil {
@@ -38,6 +39,19 @@ func appToService(app *fargo.Application) []*registry.Service {
 			json.Unmarshal([]byte(k), &metadata)
 		}
 
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+
+		// set eureka state
+		metadata["status"] = string(instance.Status)
+		if len(instance.DataCenterInfo.Name) > 0 {
+			metadata["datacenter"] = instance.DataCenterInfo.Name
+		}
+		if zone := instanceZone(instance); len(zone) > 0 {
+			metadata["zone"] = zone
+		}
+
 		// get existing service
 		service, ok := serviceMap[version]
 		if !ok {
@@ -85,7 +99,7 @@ func serviceToInstance(service *registry.Service) (*fargo.Instance, error) {
 		VipAddress:       node.Address,
 		SecureVipAddress: node.Address,
 		Port:             node.Port,
-		Status:           fargo.UP,
+		Status:           nodeStatus(node),
 		UniqueID: func(i fargo.Instance) string {
 			return fmt.Sprintf("%s:%s", node.Address, node.Id)
 		},
@@ -108,5 +122,61 @@ func serviceToInstance(service *registry.Service) (*fargo.Instance, error) {
 		instance.SetMetadataString("metadata", string(b))
 	}
 
+	// set zone as spring cloud expects it
+	if zone := node.Metadata["zone"]; len(zone) > 0 {
+		instance.SetMetadataString("zone", zone)
+	}
+
 	return instance, nil
 }
+
+// nodeStatus returns the status set in the node metadata, by default UP
+func nodeStatus(node *registry.Node) fargo.StatusType {
+	status := fargo.StatusType(strings.ToUpper(node.Metadata["status"]))
+
+	switch status {
+	case fargo.UP, fargo.DOWN, fargo.STARTING, fargo.OUTOFSERVICE:
+		return status
+	default:
+		return fargo.UP
+	}
+}
+
+// instanceZone returns the zone of an instance, set in the instance
+// metadata by spring cloud or the availability zone of an AWS instance
+func instanceZone(instance *fargo.Instance) string {
+	if zone, err := instance.Metadata.GetString("zone"); err == nil && len(zone) > 0 {
+		return zone
+	}
+	return instance.DataCenterInfo.Metadata.AvailabilityZone
+}
+
+// filterStatus returns the services with only the nodes with one of the statuses
+func filterStatus(services []*registry.Service, statuses []fargo.StatusType) []*registry.Service {
+	var filtered []*registry.Service
+
+	for _, service := range services {
+		var nodes []*registry.Node
+		for _, node := range service.Nodes {
+			if hasStatus(fargo.StatusType(node.Metadata["status"]), statuses) {
+				nodes = append(nodes, node)
+			}
+		}
+		if len(nodes) == 0 {
+			continue
+		}
+		service.Nodes = nodes
+		filtered = append(filtered, service)
+	}
+
+	return filtered
+}
+
+func hasStatus(status fargo.StatusType, statuses []fargo.StatusType) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -85,3 +85,89 @@ func TestServiceToInstance(t *testing.T) {
 		}
 	}
 }
+
+func TestServiceToInstanceStatus(t *testing.T) {
+	testData := []struct {
+		metadata map[string]string
+		status   fargo.StatusType
+	}{
+		{nil, fargo.UP},
+		{map[string]string{"status": "down"}, fargo.DOWN},
+		{map[string]string{"status": "OUT_OF_SERVICE"}, fargo.OUTOFSERVICE},
+		{map[string]string{"status": "invalid"}, fargo.UP},
+	}
+
+	for _, test := range testData {
+		service := &registry.Service{
+			Name:  "service-name",
+			Nodes: []*registry.Node{{Id: "node0", Metadata: test.metadata}},
+		}
+
+		instance, err := serviceToInstance(service)
+		if err != nil {
+			t.Fatal("Unexpected serviceToInstance error:", err)
+		}
+
+		if instance.Status != test.status {
+			t.Errorf("Unexpected instance.Status for %v: want %v, got %v", test.metadata, test.status, instance.Status)
+		}
+	}
+}
+
+func TestAppToService(t *testing.T) {
+	service := &registry.Service{
+		Name:    "service-name",
+		Version: "service-version",
+		Nodes: []*registry.Node{{
+			Id:       "node0",
+			Address:  "node0.example.com",
+			Port:     1234,
+			Metadata: map[string]string{"zone": "zone-a"},
+		}},
+	}
+
+	instance, err := serviceToInstance(service)
+	if err != nil {
+		t.Fatal("Unexpected serviceToInstance error:", err)
+	}
+
+	aws := *instance
+	aws.Metadata = fargo.InstanceMetadata{}
+	aws.SetMetadataString("version", service.Version)
+	aws.Status = fargo.DOWN
+	aws.DataCenterInfo = fargo.DataCenterInfo{
+		Name:     fargo.Amazon,
+		Metadata: fargo.AmazonMetadataType{AvailabilityZone: "us-east-1a"},
+	}
+
+	services := appToService(&fargo.Application{
+		Name:      "SERVICE-NAME",
+		Instances: []*fargo.Instance{instance, &aws},
+	})
+
+	if len(services) != 1 || len(services[0].Nodes) != 2 {
+		t.Fatalf("Expected 1 service with 2 nodes, got %+v", services)
+	}
+
+	if services[0].Version != service.Version {
+		t.Errorf("Unexpected version: want %s, got %s", service.Version, services[0].Version)
+	}
+
+	expected := []map[string]string{
+		{"status": "UP", "datacenter": fargo.MyOwn, "zone": "zone-a"},
+		{"status": "DOWN", "datacenter": fargo.Amazon, "zone": "us-east-1a"},
+	}
+
+	for i, md := range expected {
+		for k, v := range md {
+			if got := services[0].Nodes[i].Metadata[k]; got != v {
+				t.Errorf("Unexpected node %d metadata %s: want %s, got %s", i, k, v, got)
+			}
+		}
+	}
+
+	up := filterStatus(services, []fargo.StatusType{fargo.UP})
+	if len(up) != 1 || len(up[0].Nodes) != 1 || up[0].Nodes[0].Metadata["status"] != "UP" {
+		t.Errorf("Expected only the UP node, got %+v", up)
+	}
+}
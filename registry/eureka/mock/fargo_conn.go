@@ -32,6 +32,15 @@ type FargoConnection struct {
 	heartBeatInstanceReturns struct {
 		result1 error
 	}
+	UpdateInstanceStatusStub        func(*fargo.Instance, fargo.StatusType) error
+	updateInstanceStatusMutex       sync.RWMutex
+	updateInstanceStatusArgsForCall []struct {
+		arg1 *fargo.Instance
+		arg2 fargo.StatusType
+	}
+	updateInstanceStatusReturns struct {
+		result1 error
+	}
 	GetInstanceStub        func(string, string) (*fargo.Instance, error)
 	getInstanceMutex       sync.RWMutex
 	getInstanceArgsForCall []struct {
@@ -166,6 +175,39 @@ func (fake *FargoConnection) HeartBeatInstanceReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FargoConnection) UpdateInstanceStatus(arg1 *fargo.Instance, arg2 fargo.StatusType) error {
+	fake.updateInstanceStatusMutex.Lock()
+	fake.updateInstanceStatusArgsForCall = append(fake.updateInstanceStatusArgsForCall, struct {
+		arg1 *fargo.Instance
+		arg2 fargo.StatusType
+	}{arg1, arg2})
+	fake.updateInstanceStatusMutex.Unlock()
+	if fake.UpdateInstanceStatusStub != nil {
+		return fake.UpdateInstanceStatusStub(arg1, arg2)
+	} else {
+		return fake.updateInstanceStatusReturns.result1
+	}
+}
+
+func (fake *FargoConnection) UpdateInstanceStatusCallCount() int {
+	fake.updateInstanceStatusMutex.RLock()
+	defer fake.updateInstanceStatusMutex.RUnlock()
+	return len(fake.updateInstanceStatusArgsForCall)
+}
+
+func (fake *FargoConnection) UpdateInstanceStatusArgsForCall(i int) (*fargo.Instance, fargo.StatusType) {
+	fake.updateInstanceStatusMutex.RLock()
+	defer fake.updateInstanceStatusMutex.RUnlock()
+	return fake.updateInstanceStatusArgsForCall[i].arg1, fake.updateInstanceStatusArgsForCall[i].arg2
+}
+
+func (fake *FargoConnection) UpdateInstanceStatusReturns(result1 error) {
+	fake.UpdateInstanceStatusStub = nil
+	fake.updateInstanceStatusReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FargoConnection) GetInstance(arg1 string, arg2 string) (*fargo.Instance, error) {
 	fake.getInstanceMutex.Lock()
 	fake.getInstanceArgsForCall = append(fake.getInstanceArgsForCall, struct {
@@ -3,6 +3,7 @@ package eureka
 import (
 	"net/http"
 
+	"github.com/hudl/fargo"
 	"github.com/micro/go-micro/registry"
 	"golang.org/x/net/context"
 	"golang.org/x/oauth2"
@@ -27,3 +28,29 @@ func OAuth2ClientCredentials(clientID, clientSecret, tokenURL string) registry.O
 		o.Context = context.WithValue(o.Context, contextHttpClient{}, newOAuthClient(c))
 	}
 }
+
+type contextStatus struct{}
+
+type contextStatuses struct{}
+
+// Status sets the status a service is registered with, by default
+// fargo.UP. It takes precedence over a "status" node metadata value.
+func Status(status fargo.StatusType) registry.RegisterOption {
+	return func(o *registry.RegisterOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, contextStatus{}, status)
+	}
+}
+
+// Statuses sets the instance statuses returned by GetService and
+// Watch, by default only instances which are fargo.UP are returned
+func Statuses(statuses ...fargo.StatusType) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, contextStatuses{}, statuses)
+	}
+}
@@ -9,16 +9,18 @@ import (
 )
 
 type eurekaWatcher struct {
-	conn    fargoConnection
-	exit    chan bool
-	results chan *registry.Result
+	conn     fargoConnection
+	statuses []fargo.StatusType
+	exit     chan bool
+	results  chan *registry.Result
 }
 
-func newWatcher(conn fargoConnection) registry.Watcher {
+func newWatcher(conn fargoConnection, statuses []fargo.StatusType) registry.Watcher {
 	w := &eurekaWatcher{
-		conn:    conn,
-		exit:    make(chan bool),
-		results: make(chan *registry.Result),
+		conn:     conn,
+		statuses: statuses,
+		exit:     make(chan bool),
+		results:  make(chan *registry.Result),
 	}
 
 	go w.poll()
@@ -73,16 +75,16 @@ func (e *eurekaWatcher) watch(ch <-chan fargo.AppUpdate, done chan struct{}) {
 			for _, instance := range u.App.Instances {
 				var action string
 
-				switch instance.Status {
+				switch {
 				// update
-				case fargo.UP:
+				case hasStatus(instance.Status, e.statuses):
 					action = "update"
+				// skip until started
+				case instance.Status == fargo.STARTING:
+					continue
 				// delete
-				case fargo.OUTOFSERVICE, fargo.UNKNOWN, fargo.DOWN:
-					action = "delete"
-				// skip
 				default:
-					continue
+					action = "delete"
 				}
 
 				// construct the service with a single node
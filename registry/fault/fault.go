@@ -0,0 +1,298 @@
+// Package fault provides a registry which injects faults for resilience testing
+package fault
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+)
+
+/*
+	The fault registry wraps a registry and injects faults into its calls,
+	each with a probability from 0 to 1. It's meant for testing how clients
+	and selectors cope with a flaky registry.
+
+	Calls can be delayed or fail, GetService and ListServices can return no
+	services or the previous result, and watch events can be dropped or sent
+	twice. The rules can be replaced at any time with Set.
+*/
+
+// Fault is a registry which injects faults
+type Fault interface {
+	registry.Registry
+	// Set replaces the rules of the faults to inject
+	Set(opts ...Option)
+	// Options returns the current rules
+	Options() Options
+}
+
+type Options struct {
+	// Latency added to calls
+	Latency            time.Duration
+	LatencyProbability float64
+	// Error returned from calls
+	Error            error
+	ErrorProbability float64
+	// EmptyProbability of GetService and ListServices returning no services
+	EmptyProbability float64
+	// StaleProbability of GetService and ListServices returning the previous result
+	StaleProbability float64
+	// DropProbability of a watch event being dropped
+	DropProbability float64
+	// DuplicateProbability of a watch event being sent twice
+	DuplicateProbability float64
+	// Methods to inject faults into, by default all of them
+	Methods []string
+}
+
+type Option func(o *Options)
+
+type fault struct {
+	registry.Registry
+
+	sync.RWMutex
+	opts Options
+	// copies of the previous results for stale faults
+	services map[string][]*registry.Service
+	list     []*registry.Service
+}
+
+// The methods faults can be injected into. Next is the Next method of watchers.
+const (
+	Register     = "Register"
+	Deregister   = "Deregister"
+	GetService   = "GetService"
+	ListServices = "ListServices"
+	Watch        = "Watch"
+	Next         = "Next"
+)
+
+var (
+	// ErrInjected is returned by calls when no error is set
+	ErrInjected = errors.New("fault: injected error")
+)
+
+// Latency delays calls by d with the probability p
+func Latency(d time.Duration, p float64) Option {
+	return func(o *Options) {
+		o.Latency = d
+		o.LatencyProbability = p
+	}
+}
+
+// Error fails calls with err with the probability p
+func Error(err error, p float64) Option {
+	return func(o *Options) {
+		o.Error = err
+		o.ErrorProbability = p
+	}
+}
+
+// Empty returns no services from GetService and ListServices with the probability p
+func Empty(p float64) Option {
+	return func(o *Options) {
+		o.EmptyProbability = p
+	}
+}
+
+// Stale returns the previous result of GetService and ListServices with the probability p
+func Stale(p float64) Option {
+	return func(o *Options) {
+		o.StaleProbability = p
+	}
+}
+
+// Drop drops watch events with the probability p
+func Drop(p float64) Option {
+	return func(o *Options) {
+		o.DropProbability = p
+	}
+}
+
+// Duplicate sends watch events twice with the probability p
+func Duplicate(p float64) Option {
+	return func(o *Options) {
+		o.DuplicateProbability = p
+	}
+}
+
+// Methods only injects faults into the methods e.g GetService or Next
+func Methods(methods ...string) Option {
+	return func(o *Options) {
+		o.Methods = methods
+	}
+}
+
+func chance(p float64) bool {
+	return p > 0 && rand.Float64() < p
+}
+
+// rules returns the current rules if faults are injected into the method
+func (f *fault) rules(method string) (Options, bool) {
+	f.RLock()
+	opts := f.opts
+	f.RUnlock()
+
+	if len(opts.Methods) == 0 {
+		return opts, true
+	}
+
+	for _, m := range opts.Methods {
+		if m == method {
+			return opts, true
+		}
+	}
+
+	return opts, false
+}
+
+// inject delays the call and returns an error as the rules of the method decide
+func (f *fault) inject(method string) (Options, bool, error) {
+	opts, ok := f.rules(method)
+	if !ok {
+		return opts, false, nil
+	}
+
+	if chance(opts.LatencyProbability) {
+		time.Sleep(opts.Latency)
+	}
+
+	if chance(opts.ErrorProbability) {
+		if opts.Error != nil {
+			return opts, true, opts.Error
+		}
+		return opts, true, ErrInjected
+	}
+
+	return opts, true, nil
+}
+
+func (f *fault) Register(s *registry.Service, opts ...registry.RegisterOption) error {
+	if _, _, err := f.inject(Register); err != nil {
+		return err
+	}
+	return f.Registry.Register(s, opts...)
+}
+
+func (f *fault) Deregister(s *registry.Service) error {
+	if _, _, err := f.inject(Deregister); err != nil {
+		return err
+	}
+	return f.Registry.Deregister(s)
+}
+
+func (f *fault) GetService(name string) ([]*registry.Service, error) {
+	opts, ok, err := f.inject(GetService)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && chance(opts.EmptyProbability) {
+		return []*registry.Service{}, nil
+	}
+
+	if ok && chance(opts.StaleProbability) {
+		f.RLock()
+		services, stale := f.services[name]
+		f.RUnlock()
+
+		if stale {
+			return copyServices(services), nil
+		}
+	}
+
+	services, err := f.Registry.GetService(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Lock()
+	f.services[name] = copyServices(services)
+	f.Unlock()
+
+	return services, nil
+}
+
+func (f *fault) ListServices() ([]*registry.Service, error) {
+	opts, ok, err := f.inject(ListServices)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && chance(opts.EmptyProbability) {
+		return []*registry.Service{}, nil
+	}
+
+	if ok && chance(opts.StaleProbability) {
+		f.RLock()
+		services := f.list
+		f.RUnlock()
+
+		if services != nil {
+			return copyServices(services), nil
+		}
+	}
+
+	services, err := f.Registry.ListServices()
+	if err != nil {
+		return nil, err
+	}
+
+	f.Lock()
+	f.list = copyServices(services)
+	f.Unlock()
+
+	return services, nil
+}
+
+func (f *fault) Watch() (registry.Watcher, error) {
+	if _, _, err := f.inject(Watch); err != nil {
+		return nil, err
+	}
+
+	w, err := f.Registry.Watch()
+	if err != nil {
+		return nil, err
+	}
+
+	return &faultWatcher{
+		fault:   f,
+		watcher: w,
+	}, nil
+}
+
+func (f *fault) Set(opts ...Option) {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	f.Lock()
+	f.opts = options
+	f.Unlock()
+}
+
+func (f *fault) Options() Options {
+	f.RLock()
+	defer f.RUnlock()
+	return f.opts
+}
+
+func (f *fault) String() string {
+	return f.Registry.String()
+}
+
+// New returns a registry which injects faults into the calls of r
+func New(r registry.Registry, opts ...Option) Fault {
+	f := &fault{
+		Registry: r,
+		services: make(map[string][]*registry.Service),
+	}
+
+	f.Set(opts...)
+
+	return f
+}
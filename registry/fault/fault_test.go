@@ -0,0 +1,168 @@
+package fault
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-plugins/registry/memory"
+)
+
+func testService(id string) *registry.Service {
+	return &registry.Service{
+		Name:    "foo",
+		Version: "1.0.0",
+		Nodes: []*registry.Node{
+			{Id: id, Address: "localhost", Port: 8080},
+		},
+	}
+}
+
+// results returns the results of the watcher until it's stopped
+func results(w registry.Watcher) chan *registry.Result {
+	ch := make(chan *registry.Result, 10)
+	go func() {
+		for {
+			r, err := w.Next()
+			if err != nil {
+				close(ch)
+				return
+			}
+			ch <- r
+		}
+	}()
+	return ch
+}
+
+func hasNode(r *registry.Result, id string) bool {
+	for _, n := range r.Service.Nodes {
+		if n.Id == id {
+			return true
+		}
+	}
+	return false
+}
+
+func next(t *testing.T, ch chan *registry.Result) *registry.Result {
+	select {
+	case r := <-ch:
+		return r
+	case <-time.After(time.Second):
+		t.Fatal("Expected watch result")
+	}
+	return nil
+}
+
+func TestError(t *testing.T) {
+	expected := errors.New("boom")
+
+	f := New(memory.NewRegistry(), Error(expected, 1), Methods(GetService))
+
+	if err := f.Register(testService("foo-1")); err != nil {
+		t.Fatalf("Expected register to succeed, got %v", err)
+	}
+
+	if _, err := f.GetService("foo"); err != expected {
+		t.Fatalf("Expected injected error, got %v", err)
+	}
+
+	// rules are replaced at runtime
+	f.Set()
+
+	services, err := f.GetService("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(services) != 1 || len(services[0].Nodes) != 1 {
+		t.Fatalf("Expected foo-1, got %+v", services)
+	}
+
+	f.Set(Error(nil, 1))
+
+	if _, err := f.ListServices(); err != ErrInjected {
+		t.Fatalf("Expected ErrInjected, got %v", err)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	f := New(memory.NewRegistry(), Latency(time.Millisecond*50, 1))
+
+	start := time.Now()
+	f.ListServices()
+
+	if d := time.Since(start); d < time.Millisecond*50 {
+		t.Fatalf("Expected call to be delayed 50ms, took %v", d)
+	}
+}
+
+func TestEmptyAndStale(t *testing.T) {
+	f := New(memory.NewRegistry())
+
+	f.Register(testService("foo-1"))
+
+	if services, _ := f.GetService("foo"); len(services) != 1 {
+		t.Fatalf("Expected foo, got %+v", services)
+	}
+
+	f.Set(Empty(1))
+
+	if services, err := f.GetService("foo"); err != nil || len(services) != 0 {
+		t.Fatalf("Expected no services, got %+v %v", services, err)
+	}
+
+	if services, err := f.ListServices(); err != nil || len(services) != 0 {
+		t.Fatalf("Expected no services, got %+v %v", services, err)
+	}
+
+	f.Set()
+	f.Register(testService("foo-2"))
+	f.Set(Stale(1))
+
+	// foo-2 isn't in the previous result
+	services, err := f.GetService("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(services[0].Nodes) != 1 || services[0].Nodes[0].Id != "foo-1" {
+		t.Fatalf("Expected the stale result with only foo-1, got %+v", services[0].Nodes)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	f := New(memory.NewRegistry(), Duplicate(1))
+
+	w, err := f.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	ch := results(w)
+
+	f.Register(testService("foo-1"))
+
+	for i := 0; i < 2; i++ {
+		if r := next(t, ch); !hasNode(r, "foo-1") {
+			t.Fatalf("Expected foo-1 twice, got %+v", r.Service.Nodes)
+		}
+	}
+
+	f.Set(Drop(1))
+	f.Register(testService("foo-2"))
+
+	select {
+	case r := <-ch:
+		t.Fatalf("Expected the event to be dropped, got %+v", r.Service.Nodes)
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	f.Set()
+	f.Register(testService("foo-3"))
+
+	if r := next(t, ch); !hasNode(r, "foo-3") {
+		t.Fatalf("Expected foo-3, got %+v", r.Service.Nodes)
+	}
+}
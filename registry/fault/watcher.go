@@ -0,0 +1,60 @@
+package fault
+
+import (
+	"sync"
+
+	"github.com/micro/go-micro/registry"
+)
+
+// faultWatcher drops and duplicates the results of the registry's watcher
+type faultWatcher struct {
+	fault   *fault
+	watcher registry.Watcher
+
+	sync.Mutex
+	// a result to send again
+	duplicate *registry.Result
+}
+
+func (w *faultWatcher) Next() (*registry.Result, error) {
+	w.Lock()
+	r := w.duplicate
+	w.duplicate = nil
+	w.Unlock()
+
+	if r != nil {
+		return r, nil
+	}
+
+	for {
+		r, err := w.watcher.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		opts, ok, err := w.fault.inject(Next)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return r, nil
+		}
+
+		if chance(opts.DropProbability) {
+			continue
+		}
+
+		if chance(opts.DuplicateProbability) {
+			w.Lock()
+			w.duplicate = r
+			w.Unlock()
+		}
+
+		return r, nil
+	}
+}
+
+func (w *faultWatcher) Stop() {
+	w.watcher.Stop()
+}
@@ -0,0 +1,299 @@
+// Package file provides a static registry loaded from a YAML or JSON file
+package file
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/cmd"
+	"github.com/micro/go-micro/registry"
+	hash "github.com/mitchellh/hashstructure"
+	"github.com/pborman/uuid"
+	"golang.org/x/net/context"
+)
+
+type fileRegistry struct {
+	path     string
+	interval time.Duration
+
+	sync.RWMutex
+	data     []byte
+	services map[string][]*registry.Service
+	local    map[string][]*registry.Service
+	watchers map[string]*fileWatcher
+}
+
+// file is the format of the registry file
+type file struct {
+	Services []*registry.Service `json:"services"`
+}
+
+var (
+	// DefaultPath is the file read when no path is specified
+	DefaultPath = "registry.json"
+	// DefaultInterval is how often the file is checked for changes
+	DefaultInterval = time.Second * 5
+
+	timeout = time.Millisecond * 10
+)
+
+func init() {
+	cmd.DefaultRegistries["file"] = NewRegistry
+}
+
+func decode(path string, b []byte) (map[string][]*registry.Service, error) {
+	var f *file
+	var err error
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &f)
+	default:
+		err = json.Unmarshal(b, &f)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	services := make(map[string][]*registry.Service)
+	if f == nil {
+		return services, nil
+	}
+
+	for _, s := range f.Services {
+		if s == nil {
+			continue
+		}
+		if len(s.Name) == 0 {
+			return nil, errors.New("service name is required")
+		}
+		services[s.Name] = addServices(services[s.Name], []*registry.Service{s})
+	}
+
+	return services, nil
+}
+
+// diff compares two sets of services and returns the results
+// required to get a watcher from the old state to the new one
+func diff(old, neu map[string][]*registry.Service) []*registry.Result {
+	var results []*registry.Result
+
+	for name, services := range neu {
+		for _, s := range services {
+			o := findVersion(old[name], s.Version)
+			if o == nil {
+				results = append(results, &registry.Result{Action: "create", Service: s})
+				continue
+			}
+
+			h1, err1 := hash.Hash(o, nil)
+			h2, err2 := hash.Hash(s, nil)
+			if err1 == nil && err2 == nil && h1 == h2 {
+				continue
+			}
+
+			results = append(results, &registry.Result{Action: "update", Service: s})
+
+			// nodes which disappeared from the version are deleted
+			if nodes := delNodes(o.Nodes, s.Nodes); len(nodes) > 0 {
+				results = append(results, &registry.Result{
+					Action:  "delete",
+					Service: copyService(o, nodes),
+				})
+			}
+		}
+	}
+
+	for name, services := range old {
+		for _, s := range services {
+			if findVersion(neu[name], s.Version) == nil {
+				results = append(results, &registry.Result{Action: "delete", Service: s})
+			}
+		}
+	}
+
+	return results
+}
+
+func (f *fileRegistry) load() error {
+	b, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.RLock()
+	unchanged := f.data != nil && string(b) == string(f.data)
+	f.RUnlock()
+
+	if unchanged {
+		return nil
+	}
+
+	services, err := decode(f.path, b)
+	if err != nil {
+		return err
+	}
+
+	f.Lock()
+	old := f.services
+	f.services = services
+	f.data = b
+	f.Unlock()
+
+	for _, r := range diff(old, services) {
+		f.watch(r)
+	}
+
+	return nil
+}
+
+func (f *fileRegistry) run() {
+	t := time.NewTicker(f.interval)
+	defer t.Stop()
+
+	for _ = range t.C {
+		if err := f.load(); err != nil {
+			log.Logf("File registry error loading %s: %v", f.path, err)
+		}
+	}
+}
+
+func (f *fileRegistry) watch(r *registry.Result) {
+	var watchers []*fileWatcher
+
+	f.RLock()
+	for _, w := range f.watchers {
+		watchers = append(watchers, w)
+	}
+	f.RUnlock()
+
+	for _, w := range watchers {
+		select {
+		case <-w.exit:
+			f.Lock()
+			delete(f.watchers, w.id)
+			f.Unlock()
+		default:
+			select {
+			case w.res <- r:
+			case <-time.After(timeout):
+			}
+		}
+	}
+}
+
+func (f *fileRegistry) GetService(name string) ([]*registry.Service, error) {
+	f.RLock()
+	services := merge(f.services[name], f.local[name])
+	f.RUnlock()
+
+	if len(services) == 0 {
+		return nil, registry.ErrNotFound
+	}
+	return services, nil
+}
+
+func (f *fileRegistry) ListServices() ([]*registry.Service, error) {
+	names := make(map[string]bool)
+
+	f.RLock()
+	for name := range f.services {
+		names[name] = true
+	}
+	for name := range f.local {
+		names[name] = true
+	}
+	f.RUnlock()
+
+	var services []*registry.Service
+	for name := range names {
+		services = append(services, &registry.Service{Name: name})
+	}
+	return services, nil
+}
+
+// Register adds the service to the local process only; the file is never written
+func (f *fileRegistry) Register(s *registry.Service, opts ...registry.RegisterOption) error {
+	go f.watch(&registry.Result{Action: "update", Service: s})
+
+	f.Lock()
+	f.local[s.Name] = addServices(f.local[s.Name], []*registry.Service{copyService(s, s.Nodes)})
+	f.Unlock()
+	return nil
+}
+
+func (f *fileRegistry) Deregister(s *registry.Service) error {
+	go f.watch(&registry.Result{Action: "delete", Service: s})
+
+	f.Lock()
+	if services := delServices(f.local[s.Name], []*registry.Service{s}); len(services) == 0 {
+		delete(f.local, s.Name)
+	} else {
+		f.local[s.Name] = services
+	}
+	f.Unlock()
+	return nil
+}
+
+func (f *fileRegistry) Watch() (registry.Watcher, error) {
+	w := &fileWatcher{
+		exit: make(chan bool),
+		res:  make(chan *registry.Result),
+		id:   uuid.NewUUID().String(),
+	}
+
+	f.Lock()
+	f.watchers[w.id] = w
+	f.Unlock()
+	return w, nil
+}
+
+func (f *fileRegistry) String() string {
+	return "file"
+}
+
+func NewRegistry(opts ...registry.Option) registry.Registry {
+	options := registry.Options{
+		Context: context.Background(),
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	path := DefaultPath
+	if len(options.Addrs) > 0 && len(options.Addrs[0]) > 0 {
+		path = options.Addrs[0]
+	}
+	if p, ok := options.Context.Value(pathKey{}).(string); ok {
+		path = p
+	}
+
+	interval := DefaultInterval
+	if i, ok := options.Context.Value(intervalKey{}).(time.Duration); ok && i > 0 {
+		interval = i
+	}
+
+	f := &fileRegistry{
+		path:     path,
+		interval: interval,
+		services: make(map[string][]*registry.Service),
+		local:    make(map[string][]*registry.Service),
+		watchers: make(map[string]*fileWatcher),
+	}
+
+	if err := f.load(); err != nil {
+		log.Logf("File registry error loading %s: %v", path, err)
+	}
+
+	go f.run()
+
+	return f
+}
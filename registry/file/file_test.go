@@ -0,0 +1,237 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+)
+
+var (
+	testYAML = `
+services:
+- name: foo
+  version: 1.0.0
+  metadata:
+    owner: team-a
+  nodes:
+  - id: foo-1
+    address: 10.0.0.1
+    port: 8080
+  - id: foo-2
+    address: 10.0.0.2
+    port: 8080
+- name: bar
+  version: latest
+  nodes:
+  - id: bar-1
+    address: 10.0.0.3
+    port: 9090
+`
+
+	testJSON = `{
+	"services": [
+		{
+			"name": "foo",
+			"version": "1.0.0",
+			"metadata": {"owner": "team-a"},
+			"nodes": [
+				{"id": "foo-1", "address": "10.0.0.1", "port": 8080}
+			]
+		},
+		{
+			"name": "foo",
+			"version": "1.0.1",
+			"nodes": [
+				{"id": "foo-3", "address": "10.0.0.4", "port": 8080}
+			]
+		}
+	]
+}`
+)
+
+func writeFile(t *testing.T, path, data string) {
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	services, err := decode("services.yaml", []byte(testYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(services["foo"]) != 1 || len(services["foo"][0].Nodes) != 2 {
+		t.Fatalf("Expected 1 foo service with 2 nodes, got %+v", services["foo"])
+	}
+
+	if owner := services["foo"][0].Metadata["owner"]; owner != "team-a" {
+		t.Fatalf("Expected owner metadata team-a, got %s", owner)
+	}
+
+	if port := services["bar"][0].Nodes[0].Port; port != 9090 {
+		t.Fatalf("Expected port 9090, got %d", port)
+	}
+
+	services, err = decode("services.json", []byte(testJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(services["foo"]) != 2 {
+		t.Fatalf("Expected 2 versions of foo, got %d", len(services["foo"]))
+	}
+
+	if _, err := decode("services.json", []byte(`{"services": [{"version": "1"}]}`)); err == nil {
+		t.Fatal("Expected error for service without a name")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old, err := decode("services.yaml", []byte(testYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	neu, err := decode("services.json", []byte(testJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]int)
+	for _, r := range diff(old, neu) {
+		counts[r.Action]++
+
+		if r.Action == "delete" && r.Service.Name == "foo" {
+			if len(r.Service.Nodes) != 1 || r.Service.Nodes[0].Id != "foo-2" {
+				t.Fatalf("Expected foo-2 to be deleted, got %+v", r.Service.Nodes)
+			}
+		}
+	}
+
+	// foo 1.0.1 created, foo 1.0.0 updated, foo-2 and bar deleted
+	expected := map[string]int{"create": 1, "update": 1, "delete": 2}
+	for action, count := range expected {
+		if counts[action] != count {
+			t.Fatalf("Expected %d %s results, got %d", count, action, counts[action])
+		}
+	}
+
+	if results := diff(neu, neu); len(results) != 0 {
+		t.Fatalf("Expected no results for unchanged services, got %d", len(results))
+	}
+}
+
+func TestFileRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "services.yaml")
+	writeFile(t, path, testYAML)
+
+	r := NewRegistry(Path(path), Interval(time.Millisecond*10))
+
+	services, err := r.GetService("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 1 || len(services[0].Nodes) != 1 {
+		t.Fatalf("Expected 1 bar service with 1 node, got %+v", services)
+	}
+
+	// local registrations are merged with the file
+	local := &registry.Service{
+		Name:    "bar",
+		Version: "latest",
+		Nodes: []*registry.Node{
+			{Id: "bar-2", Address: "10.0.0.5", Port: 9090},
+		},
+	}
+	if err := r.Register(local); err != nil {
+		t.Fatal(err)
+	}
+
+	services, err = r.GetService("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 1 || len(services[0].Nodes) != 2 {
+		t.Fatalf("Expected 1 bar service with 2 nodes, got %+v", services)
+	}
+
+	if err := r.Deregister(local); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := r.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	// drain the results of deregistering
+	go func() {
+		for {
+			if _, err := w.Next(); err != nil {
+				return
+			}
+		}
+	}()
+
+	writeFile(t, path, `services: [{name: baz, version: "1", nodes: [{id: baz-1}]}]`)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := r.GetService("baz"); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the file to be reloaded")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if _, err := r.GetService("bar"); err != registry.ErrNotFound {
+		t.Fatalf("Expected %v, got %v", registry.ErrNotFound, err)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "services.json")
+	writeFile(t, path, testJSON)
+
+	r := NewRegistry(Path(path), Interval(time.Millisecond*10))
+
+	w, err := r.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	writeFile(t, path, `{"services": []}`)
+
+	deleted := make(map[string]bool)
+	for len(deleted) < 2 {
+		res, err := w.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Action != "delete" {
+			t.Fatalf("Expected delete action, got %s", res.Action)
+		}
+		deleted[res.Service.Version] = true
+	}
+}
@@ -0,0 +1,32 @@
+package file
+
+import (
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"golang.org/x/net/context"
+)
+
+type pathKey struct{}
+type intervalKey struct{}
+
+// Path sets the YAML or JSON file to load services from.
+// Files ending in .yaml or .yml are parsed as YAML, anything else as JSON.
+func Path(p string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, pathKey{}, p)
+	}
+}
+
+// Interval sets how often the file is checked for changes
+func Interval(d time.Duration) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, intervalKey{}, d)
+	}
+}
@@ -0,0 +1,31 @@
+package file
+
+import (
+	"errors"
+
+	"github.com/micro/go-micro/registry"
+)
+
+type fileWatcher struct {
+	id   string
+	res  chan *registry.Result
+	exit chan bool
+}
+
+func (f *fileWatcher) Next() (*registry.Result, error) {
+	select {
+	case r := <-f.res:
+		return r, nil
+	case <-f.exit:
+		return nil, errors.New("watcher stopped")
+	}
+}
+
+func (f *fileWatcher) Stop() {
+	select {
+	case <-f.exit:
+		return
+	default:
+		close(f.exit)
+	}
+}
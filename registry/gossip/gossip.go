@@ -4,7 +4,9 @@ package gossip
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 
@@ -14,6 +16,7 @@ import (
 	"github.com/micro/go-micro/registry"
 	"github.com/mitchellh/hashstructure"
 	"github.com/pborman/uuid"
+	"golang.org/x/net/context"
 )
 
 type action int
@@ -43,6 +46,21 @@ type gossipRegistry struct {
 
 	s    sync.RWMutex
 	subs map[string]chan *registry.Result
+
+	keyring *memberlist.Keyring
+
+	// updates to expire by hash, guarded by emtx
+	emtx   sync.Mutex
+	expiry map[uint64]*update
+	// hashes of snapshot updates not yet confirmed by gossip, by node
+	unconfirmed map[string]uint64
+
+	// closed when the stop context is done
+	exit <-chan struct{}
+
+	// memberlist once created, guarded by mtx
+	mtx    sync.RWMutex
+	member *memberlist.Memberlist
 }
 
 type update struct {
@@ -98,6 +116,19 @@ func addServices(old, neu []*registry.Service) []*registry.Service {
 	return old
 }
 
+// copyServices copies services so they can be read outside the lock
+func copyServices(services []*registry.Service) []*registry.Service {
+	var cp []*registry.Service
+	for _, s := range services {
+		service := new(registry.Service)
+		*service = *s
+		service.Nodes = make([]*registry.Node, len(s.Nodes))
+		copy(service.Nodes, s.Nodes)
+		cp = append(cp, service)
+	}
+	return cp
+}
+
 func delNodes(old, del []*registry.Node) []*registry.Node {
 	var nodes []*registry.Node
 	for _, o := range old {
@@ -223,6 +254,16 @@ func (d *delegate) MergeRemoteState(buf []byte, join bool) {
 	}
 }
 
+// numMembers is the number of members broadcasts are retransmitted to
+func (m *gossipRegistry) numMembers() int {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	if m.member == nil {
+		return 1
+	}
+	return m.member.NumMembers()
+}
+
 func (m *gossipRegistry) publish(action string, services []*registry.Service) {
 	m.s.RLock()
 	for _, sub := range m.subs {
@@ -256,31 +297,60 @@ func (m *gossipRegistry) subscribe() (chan *registry.Result, chan bool) {
 	return next, exit
 }
 
-func (m *gossipRegistry) run() {
-	var mtx sync.Mutex
-	updates := map[uint64]*update{}
+// expire returns the updates which have expired, deleting them
+// and the snapshot nodes they were waiting to be confirmed by
+func (m *gossipRegistry) expire(now int64) []*update {
+	var expired []*update
+
+	m.emtx.Lock()
+	defer m.emtx.Unlock()
+
+	for k, v := range m.expiry {
+		// check if expiry time has passed
+		if d := (v.Timestamp + v.Expires) - now; d >= 0 {
+			continue
+		}
+
+		// delete from records
+		delete(m.expiry, k)
+
+		for _, n := range v.Service.Nodes {
+			key := nodeKey(v.Service, n)
+			if hash, ok := m.unconfirmed[key]; ok && hash == k {
+				delete(m.unconfirmed, key)
+			}
+		}
+
+		// set to delete
+		v.Action = delAction
+		expired = append(expired, v)
+	}
 
+	return expired
+}
+
+func (m *gossipRegistry) run(tick time.Duration) {
 	// expiry loop
 	go func() {
-		t := time.NewTicker(ExpiryTick)
+		t := time.NewTicker(tick)
 		defer t.Stop()
 
-		for _ = range t.C {
-			now := time.Now().Unix()
-
-			mtx.Lock()
-			for k, v := range updates {
-				// check if expiry time has passed
-				if d := (v.Timestamp + v.Expires) - now; d < 0 {
-					// delete from records
-					delete(updates, k)
-					// set to delete
-					v.Action = delAction
-					// fire a new update
-					m.updates <- v
+		for {
+			select {
+			case <-m.exit:
+				return
+			case <-t.C:
+			}
+
+			// fire the updates without holding emtx which the
+			// update loop needs to handle them
+			for _, u := range m.expire(time.Now().Unix()) {
+				select {
+				case m.updates <- u:
+				case <-m.exit:
+					return
 				}
 			}
-			mtx.Unlock()
 		}
 	}()
 
@@ -297,13 +367,16 @@ func (m *gossipRegistry) run() {
 			m.Unlock()
 			go m.publish("add", []*registry.Service{u.Service})
 
+			// the node is alive so stop expiring its snapshot
+			m.confirm(u.Service)
+
 			// we need to expire the node at some point in the future
 			if u.Expires > 0 {
 				// create a hash of this service
 				if hash, err := hashstructure.Hash(u.Service, nil); err == nil {
-					mtx.Lock()
-					updates[hash] = u
-					mtx.Unlock()
+					m.emtx.Lock()
+					m.expiry[hash] = u
+					m.emtx.Unlock()
 				}
 			}
 		case delAction:
@@ -320,9 +393,9 @@ func (m *gossipRegistry) run() {
 
 			// delete from expiry checks
 			if hash, err := hashstructure.Hash(u.Service, nil); err == nil {
-				mtx.Lock()
-				delete(updates, hash)
-				mtx.Unlock()
+				m.emtx.Lock()
+				delete(m.expiry, hash)
+				m.emtx.Unlock()
 			}
 		case syncAction:
 			if u.sync == nil {
@@ -350,6 +423,8 @@ func (m *gossipRegistry) Register(s *registry.Service, opts ...registry.Register
 	}
 	m.Unlock()
 
+	m.confirm(s)
+
 	var options registry.RegisterOptions
 	for _, o := range opts {
 		o(&options)
@@ -400,12 +475,12 @@ func (m *gossipRegistry) Deregister(s *registry.Service) error {
 
 func (m *gossipRegistry) GetService(name string) ([]*registry.Service, error) {
 	m.RLock()
+	defer m.RUnlock()
 	service, ok := m.services[name]
-	m.RUnlock()
 	if !ok {
 		return nil, fmt.Errorf("Service %s not found", name)
 	}
-	return service, nil
+	return copyServices(service), nil
 }
 
 func (m *gossipRegistry) ListServices() ([]*registry.Service, error) {
@@ -433,6 +508,10 @@ func NewRegistry(opts ...registry.Option) registry.Registry {
 		o(&options)
 	}
 
+	if options.Context == nil {
+		options.Context = context.Background()
+	}
+
 	cAddrs := []string{}
 	hostname, _ := os.Hostname()
 	updates := make(chan *update, 100)
@@ -443,21 +522,48 @@ func NewRegistry(opts ...registry.Option) registry.Registry {
 		}
 	}
 
+	if join, ok := options.Context.Value(contextJoin{}).([]string); ok {
+		for _, addr := range join {
+			if len(addr) > 0 {
+				cAddrs = append(cAddrs, addr)
+			}
+		}
+	}
+
+	mr := &gossipRegistry{
+		services:    make(map[string][]*registry.Service),
+		updates:     updates,
+		subs:        make(map[string]chan *registry.Result),
+		expiry:      make(map[uint64]*update),
+		unconfirmed: make(map[string]uint64),
+	}
+
+	// never stop unless a stop context is set
+	if ctx, ok := options.Context.Value(contextStop{}).(context.Context); ok {
+		mr.exit = ctx.Done()
+	}
+
 	broadcasts := &memberlist.TransmitLimitedQueue{
-		NumNodes: func() int {
-			return len(cAddrs)
-		},
+		NumNodes:       mr.numMembers,
 		RetransmitMult: 3,
 	}
+	mr.broadcasts = broadcasts
 
-	mr := &gossipRegistry{
-		broadcasts: broadcasts,
-		services:   make(map[string][]*registry.Service),
-		updates:    updates,
-		subs:       make(map[string]chan *registry.Result),
+	// load the last snapshot so services are available straight away
+	snapshot, _ := options.Context.Value(contextSnapshot{}).(string)
+	if len(snapshot) > 0 {
+		if err := mr.loadSnapshot(snapshot); err != nil {
+			log.Logf("Error loading snapshot %s: %v", snapshot, err)
+		}
+
+		interval, ok := options.Context.Value(contextSnapshotInterval{}).(time.Duration)
+		if !ok || interval <= 0 {
+			interval = DefaultSnapshotInterval
+		}
+		go mr.snapshot(snapshot, interval)
 	}
 
-	go mr.run()
+	go mr.run(ExpiryTick)
 
 	c := memberlist.DefaultLocalConfig()
 	c.BindPort = 0
@@ -467,12 +573,41 @@ func NewRegistry(opts ...registry.Option) registry.Registry {
 		broadcasts: broadcasts,
 	}
 
-	if options.Secure {
+	if addr, ok := options.Context.Value(contextAddress{}).(string); ok {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			log.Fatalf("Error parsing address %s: %v", addr, err)
+		}
+		c.BindAddr = host
+		c.BindPort, _ = strconv.Atoi(port)
+	}
+
+	if addr, ok := options.Context.Value(contextAdvertise{}).(string); ok {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			log.Fatalf("Error parsing advertise address %s: %v", addr, err)
+		}
+		c.AdvertiseAddr = host
+		c.AdvertisePort, _ = strconv.Atoi(port)
+	}
+
+	keys, _ := options.Context.Value(contextKeys{}).([][]byte)
+
+	if options.Secure && len(keys) == 0 {
 		k, ok := options.Context.Value(contextSecretKey{}).([]byte)
 		if !ok {
 			k = DefaultKey
 		}
-		c.SecretKey = k
+		keys = [][]byte{k}
+	}
+
+	if len(keys) > 0 {
+		keyring, err := memberlist.NewKeyring(keys, keys[0])
+		if err != nil {
+			log.Fatalf("Error creating keyring: %v", err)
+		}
+		c.Keyring = keyring
+		mr.keyring = keyring
 	}
 
 	m, err := memberlist.Create(c)
@@ -480,6 +615,10 @@ func NewRegistry(opts ...registry.Option) registry.Registry {
 		log.Fatalf("Error creating memberlist: %v", err)
 	}
 
+	mr.mtx.Lock()
+	mr.member = m
+	mr.mtx.Unlock()
+
 	if len(cAddrs) > 0 {
 		_, err := m.Join(cAddrs)
 		if err != nil {
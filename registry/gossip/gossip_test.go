@@ -1,7 +1,9 @@
 package gossip
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/micro/go-micro/registry"
 )
@@ -76,3 +78,115 @@ func TestDelNodes(t *testing.T) {
 	}
 	t.Logf("Nodes %+v", nodes)
 }
+
+func newTestRegistry(t *testing.T, opts ...registry.Option) *gossipRegistry {
+	opts = append(opts, Address("127.0.0.1:0"))
+	r := NewRegistry(opts...).(*gossipRegistry)
+	t.Logf("Started gossip node %s", r.address())
+	return r
+}
+
+func (m *gossipRegistry) address() string {
+	node := m.member.LocalNode()
+	return fmt.Sprintf("%s:%d", node.Addr, node.Port)
+}
+
+// waitService waits until every registry has the expected number of nodes
+func waitService(t *testing.T, name string, nodes int, registries ...*gossipRegistry) {
+	deadline := time.Now().Add(time.Second * 10)
+
+	for _, r := range registries {
+		for {
+			services, _ := r.GetService(name)
+			var n int
+			for _, s := range services {
+				n += len(s.Nodes)
+			}
+			if n == nodes {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %d nodes of %s on %s, got %d", nodes, name, r.address(), n)
+			}
+			time.Sleep(time.Millisecond * 50)
+		}
+	}
+}
+
+func testService(name, id string) *registry.Service {
+	return &registry.Service{
+		Name:    name,
+		Version: "1.0.0",
+		Nodes: []*registry.Node{
+			{
+				Id:      id,
+				Address: "localhost",
+				Port:    9999,
+			},
+		},
+	}
+}
+
+func TestGossipKeyRotation(t *testing.T) {
+	oldKey := []byte("0123456789abcdef")
+	newKey := []byte("fedcba9876543210")
+
+	r1 := newTestRegistry(t, Keys(oldKey))
+	defer r1.member.Shutdown()
+	r2 := newTestRegistry(t, Keys(oldKey), Join(r1.address()))
+	defer r2.member.Shutdown()
+	r3 := newTestRegistry(t, Keys(oldKey), Join(r1.address()))
+	defer r3.member.Shutdown()
+
+	registries := []*gossipRegistry{r1, r2, r3}
+
+	r1.Register(testService("foo", "foo-1"))
+	waitService(t, "foo", 1, registries...)
+
+	// rotate the key on every member
+	for _, r := range registries {
+		if err := r.InstallKey(newKey); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, r := range registries {
+		if err := r.UseKey(newKey); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, r := range registries {
+		if err := r.RemoveKey(oldKey); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, r := range registries {
+		keys, err := r.ListKeys()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 1 || string(keys[0]) != string(newKey) {
+			t.Fatalf("Expected only the new key, got %q", keys)
+		}
+	}
+
+	// gossip still works with the new key
+	r3.Register(testService("bar", "bar-1"))
+	waitService(t, "bar", 1, registries...)
+
+	// a member with the old key can't join
+	r4 := NewRegistry(Keys(oldKey), Address("127.0.0.1:0")).(*gossipRegistry)
+	defer r4.member.Shutdown()
+	if _, err := r4.member.Join([]string{r1.address()}); err == nil {
+		t.Fatal("Expected a member with the old key to fail to join")
+	}
+}
+
+func TestGossipKeyringNotEncrypted(t *testing.T) {
+	r := newTestRegistry(t)
+	defer r.member.Shutdown()
+
+	if err := r.InstallKey([]byte("0123456789abcdef")); err != ErrNotEncrypted {
+		t.Fatalf("Expected %v, got %v", ErrNotEncrypted, err)
+	}
+}
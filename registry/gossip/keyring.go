@@ -0,0 +1,61 @@
+package gossip
+
+import (
+	"errors"
+)
+
+/*
+	The keys used to encrypt gossip can be rotated without downtime. Each
+	member must be changed in turn:
+
+	1. InstallKey the new key on every member, it's now accepted when decrypting
+	2. UseKey the new key on every member, it's now used to encrypt
+	3. RemoveKey the old key from every member
+*/
+
+// Keyring manages the encryption keys of a gossip registry.
+// The registry returned by NewRegistry implements Keyring.
+type Keyring interface {
+	// InstallKey adds a key to the keyring
+	InstallKey(key []byte) error
+	// UseKey changes the primary key used to encrypt messages,
+	// the key must already be installed
+	UseKey(key []byte) error
+	// RemoveKey removes a key from the keyring,
+	// the primary key can't be removed
+	RemoveKey(key []byte) error
+	// ListKeys returns the installed keys, the primary key first
+	ListKeys() ([][]byte, error)
+}
+
+var (
+	ErrNotEncrypted = errors.New("gossip encryption is not enabled")
+)
+
+func (m *gossipRegistry) InstallKey(key []byte) error {
+	if m.keyring == nil {
+		return ErrNotEncrypted
+	}
+	return m.keyring.AddKey(key)
+}
+
+func (m *gossipRegistry) UseKey(key []byte) error {
+	if m.keyring == nil {
+		return ErrNotEncrypted
+	}
+	return m.keyring.UseKey(key)
+}
+
+func (m *gossipRegistry) RemoveKey(key []byte) error {
+	if m.keyring == nil {
+		return ErrNotEncrypted
+	}
+	return m.keyring.RemoveKey(key)
+}
+
+func (m *gossipRegistry) ListKeys() ([][]byte, error) {
+	if m.keyring == nil {
+		return nil, ErrNotEncrypted
+	}
+	return m.keyring.GetKeys(), nil
+}
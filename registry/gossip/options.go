@@ -1,6 +1,8 @@
 package gossip
 
 import (
+	"time"
+
 	"github.com/micro/go-micro/registry"
 
 	"golang.org/x/net/context"
@@ -8,8 +10,102 @@ import (
 
 type contextSecretKey struct{}
 
+type contextKeys struct{}
+
+type contextAddress struct{}
+
+type contextAdvertise struct{}
+
+type contextJoin struct{}
+
+// SecretKey sets the key used to encrypt gossip when registry.Secure is set
 func SecretKey(k []byte) registry.Option {
 	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
 		o.Context = context.WithValue(o.Context, contextSecretKey{}, k)
 	}
 }
+
+// Keys enables encryption with a keyring of keys. The first key is the primary
+// key used to encrypt messages, all keys are tried when decrypting. Keys must be
+// 16, 24 or 32 bytes. Keys can be changed at runtime through the Keyring interface.
+func Keys(keys ...[]byte) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, contextKeys{}, keys)
+	}
+}
+
+// Address sets the host:port gossip binds to, by default a random port
+func Address(addr string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, contextAddress{}, addr)
+	}
+}
+
+// Advertise sets the host:port advertised to other members, such as the
+// address of a NAT. The port is only used if the bind port is set.
+func Advertise(addr string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, contextAdvertise{}, addr)
+	}
+}
+
+// Join sets the addresses of existing members to join, in
+// addition to those set with registry.Addrs
+func Join(addrs ...string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, contextJoin{}, addrs)
+	}
+}
+
+type contextSnapshot struct{}
+
+type contextSnapshotInterval struct{}
+
+// Snapshot periodically writes the known services to a file which is
+// loaded on startup, so services are available before gossip has synced
+func Snapshot(path string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, contextSnapshot{}, path)
+	}
+}
+
+// SnapshotInterval sets how often the snapshot is written
+func SnapshotInterval(d time.Duration) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, contextSnapshotInterval{}, d)
+	}
+}
+
+type contextStop struct{}
+
+// StopContext stops expiring nodes and writing snapshots once the context is
+// done, the snapshot is written a last time before stopping
+func StopContext(ctx context.Context) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, contextStop{}, ctx)
+	}
+}
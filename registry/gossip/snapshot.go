@@ -0,0 +1,204 @@
+package gossip
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/registry"
+	"github.com/mitchellh/hashstructure"
+)
+
+/*
+	The registry can periodically snapshot the services it knows of to a file.
+	On startup the snapshot is loaded so services are available before gossip
+	has synced. Services loaded from the snapshot are unconfirmed and expire
+	after ReconcileTimeout, or their original expiry if sooner, unless they are
+	received again through gossip or registered locally.
+*/
+
+var (
+	// DefaultSnapshotInterval is how often the snapshot is written
+	DefaultSnapshotInterval = time.Second * 30
+	// ReconcileTimeout is how long services loaded from a snapshot
+	// are kept without being confirmed by gossip
+	ReconcileTimeout = time.Minute
+)
+
+type snapshotEntry struct {
+	// Service with a single node
+	Service *registry.Service `json:"service"`
+	// Expires is the unix time the node expires, 0 if it doesn't
+	Expires int64 `json:"expires"`
+}
+
+type snapshotFile struct {
+	Services []*snapshotEntry `json:"services"`
+}
+
+func nodeKey(s *registry.Service, n *registry.Node) string {
+	return s.Name + "/" + s.Version + "/" + n.Id
+}
+
+// confirm stops the snapshot of the nodes of a service from expiring
+func (m *gossipRegistry) confirm(s *registry.Service) {
+	m.emtx.Lock()
+	defer m.emtx.Unlock()
+
+	for _, n := range s.Nodes {
+		key := nodeKey(s, n)
+		if hash, ok := m.unconfirmed[key]; ok {
+			delete(m.expiry, hash)
+			delete(m.unconfirmed, key)
+		}
+	}
+}
+
+// snapshotEntries returns a node per entry with its expiry time
+func (m *gossipRegistry) snapshotEntries() []*snapshotEntry {
+	// expiry of nodes
+	expires := make(map[string]int64)
+
+	m.emtx.Lock()
+	for _, u := range m.expiry {
+		for _, n := range u.Service.Nodes {
+			expires[nodeKey(u.Service, n)] = u.Timestamp + u.Expires
+		}
+	}
+	m.emtx.Unlock()
+
+	var entries []*snapshotEntry
+
+	m.RLock()
+	for _, services := range m.services {
+		for _, s := range services {
+			for _, n := range s.Nodes {
+				service := new(registry.Service)
+				*service = *s
+				service.Nodes = []*registry.Node{n}
+
+				entries = append(entries, &snapshotEntry{
+					Service: service,
+					Expires: expires[nodeKey(s, n)],
+				})
+			}
+		}
+	}
+	m.RUnlock()
+
+	return entries
+}
+
+// saveSnapshot writes the snapshot to a temporary file and renames it
+func (m *gossipRegistry) saveSnapshot(path string) error {
+	b, err := json.Marshal(&snapshotFile{Services: m.snapshotEntries()})
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// loadSnapshot adds the services of a snapshot as unconfirmed
+func (m *gossipRegistry) loadSnapshot(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var snap snapshotFile
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	timeout := int64(ReconcileTimeout.Seconds())
+
+	m.Lock()
+	m.emtx.Lock()
+	defer m.emtx.Unlock()
+	defer m.Unlock()
+
+	for _, e := range snap.Services {
+		if e.Service == nil || len(e.Service.Nodes) != 1 {
+			continue
+		}
+
+		// expire when the node would have, or when it's
+		// not confirmed within the reconcile timeout
+		expires := timeout
+		if e.Expires > 0 {
+			if e.Expires-now <= 0 {
+				continue
+			}
+			if e.Expires-now < expires {
+				expires = e.Expires - now
+			}
+		}
+
+		hash, err := hashstructure.Hash(e.Service, nil)
+		if err != nil {
+			continue
+		}
+
+		// addServices modifies the services added so pass a copy
+		m.services[e.Service.Name] = addServices(m.services[e.Service.Name], copyServices([]*registry.Service{e.Service}))
+
+		m.expiry[hash] = &update{
+			Action:    addAction,
+			Service:   e.Service,
+			Timestamp: now,
+			Expires:   expires,
+		}
+		m.unconfirmed[nodeKey(e.Service, e.Service.Nodes[0])] = hash
+	}
+
+	return nil
+}
+
+// snapshot writes the snapshot every interval and
+// a last time once the stop context is done
+func (m *gossipRegistry) snapshot(path string, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		var done bool
+
+		select {
+		case <-m.exit:
+			done = true
+		case <-t.C:
+		}
+
+		if err := m.saveSnapshot(path); err != nil {
+			log.Logf("Error writing snapshot %s: %v", path, err)
+		}
+
+		if done {
+			return
+		}
+	}
+}
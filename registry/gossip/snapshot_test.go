@@ -0,0 +1,166 @@
+package gossip
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gossip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "snapshot.json")
+
+	r1 := newTestRegistry(t, Snapshot(path), SnapshotInterval(time.Millisecond*10))
+	r1.Register(testService("foo", "foo-1"))
+	r1.Register(testService("bar", "bar-1"))
+
+	// wait for the snapshot to be written
+	deadline := time.Now().Add(time.Second * 5)
+	for {
+		var snap snapshotFile
+		if b, err := ioutil.ReadFile(path); err == nil {
+			json.Unmarshal(b, &snap)
+		}
+		if len(snap.Services) == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for snapshot")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	r1.member.Shutdown()
+
+	// restart from the snapshot
+	r2 := newTestRegistry(t, Snapshot(path))
+	defer r2.member.Shutdown()
+
+	for _, name := range []string{"foo", "bar"} {
+		services, err := r2.GetService(name)
+		if err != nil {
+			t.Fatalf("Expected %s to be loaded from the snapshot, got %v", name, err)
+		}
+		if len(services) != 1 || len(services[0].Nodes) != 1 {
+			t.Fatalf("Expected 1 service with 1 node, got %+v", services)
+		}
+	}
+
+	r2.emtx.Lock()
+	unconfirmed := len(r2.unconfirmed)
+	expiry := len(r2.expiry)
+	r2.emtx.Unlock()
+
+	if unconfirmed != 2 || expiry != 2 {
+		t.Fatalf("Expected 2 unconfirmed nodes to expire, got %d unconfirmed and %d expiring", unconfirmed, expiry)
+	}
+
+	// registering confirms the node
+	r2.Register(testService("foo", "foo-1"))
+
+	foo := testService("foo", "foo-1")
+
+	r2.emtx.Lock()
+	_, ok := r2.unconfirmed[nodeKey(foo, foo.Nodes[0])]
+	expiry = len(r2.expiry)
+	r2.emtx.Unlock()
+
+	if ok || expiry != 1 {
+		t.Fatalf("Expected foo to be confirmed, got %d expiring", expiry)
+	}
+}
+
+func TestSnapshotExpiry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gossip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "snapshot.json")
+
+	// more nodes than the updates channel holds
+	var snap snapshotFile
+	for i := 0; i < 250; i++ {
+		snap.Services = append(snap.Services, &snapshotEntry{
+			Service: testService("foo", fmt.Sprintf("foo-%d", i)),
+		})
+	}
+
+	b, err := json.Marshal(&snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tick, timeout := ExpiryTick, ReconcileTimeout
+	ExpiryTick, ReconcileTimeout = time.Millisecond*10, 0
+	defer func() {
+		ExpiryTick, ReconcileTimeout = tick, timeout
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := newTestRegistry(t, Snapshot(path), StopContext(ctx))
+	defer r.member.Shutdown()
+
+	if services, err := r.GetService("foo"); err != nil || len(services[0].Nodes) != 250 {
+		t.Fatalf("Expected 250 nodes loaded from the snapshot, got %v", err)
+	}
+
+	// every node expires without confirmation
+	deadline := time.Now().Add(time.Second * 5)
+	for {
+		if _, err := r.GetService("foo"); err != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the snapshot to expire")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	r.emtx.Lock()
+	unconfirmed := len(r.unconfirmed)
+	expiry := len(r.expiry)
+	r.emtx.Unlock()
+
+	if unconfirmed != 0 || expiry != 0 {
+		t.Fatalf("Expected no nodes left to expire, got %d unconfirmed and %d expiring", unconfirmed, expiry)
+	}
+
+	// the registry still handles updates
+	r.updates <- &update{Action: addAction, Service: testService("bar", "bar-1")}
+	waitService(t, "bar", 1, r)
+
+	// the last snapshot is written once stopped
+	cancel()
+
+	deadline = time.Now().Add(time.Second * 5)
+	for {
+		var snap snapshotFile
+		if b, err := ioutil.ReadFile(path); err == nil {
+			json.Unmarshal(b, &snap)
+		}
+		if len(snap.Services) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the last snapshot")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
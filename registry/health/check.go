@@ -0,0 +1,74 @@
+package health
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/registry"
+	proto "github.com/micro/go-micro/server/debug/proto"
+	"golang.org/x/net/context"
+)
+
+// address returns the host:port of a node
+func address(n *registry.Node) string {
+	if n.Port > 0 {
+		return net.JoinHostPort(n.Address, strconv.Itoa(n.Port))
+	}
+	return n.Address
+}
+
+// TCPCheck checks a node accepts tcp connections
+func TCPCheck(timeout time.Duration) CheckFunc {
+	return func(s *registry.Service, n *registry.Node) error {
+		c, err := net.DialTimeout("tcp", address(n), timeout)
+		if err != nil {
+			return err
+		}
+		return c.Close()
+	}
+}
+
+// HTTPCheck checks a GET request of the path returns a 2xx status
+func HTTPCheck(path string, timeout time.Duration) CheckFunc {
+	c := &http.Client{
+		Timeout: timeout,
+	}
+
+	return func(s *registry.Service, n *registry.Node) error {
+		rsp, err := c.Get("http://" + address(n) + path)
+		if err != nil {
+			return err
+		}
+		rsp.Body.Close()
+
+		if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+			return fmt.Errorf("unexpected status %s", rsp.Status)
+		}
+		return nil
+	}
+}
+
+// RPCCheck calls the Debug.Health endpoint of a node
+// and checks the status returned is "ok"
+func RPCCheck(c client.Client, timeout time.Duration) CheckFunc {
+	return func(s *registry.Service, n *registry.Node) error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		req := c.NewRequest(s.Name, "Debug.Health", &proto.HealthRequest{})
+		rsp := &proto.HealthResponse{}
+
+		if err := c.CallRemote(ctx, address(n), req, rsp); err != nil {
+			return err
+		}
+
+		if rsp.Status != "ok" {
+			return fmt.Errorf("unexpected status %s", rsp.Status)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,416 @@
+// Package health provides a registry which actively checks the health of nodes
+package health
+
+import (
+	"sync"
+	"time"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/registry"
+	"github.com/pborman/uuid"
+)
+
+/*
+	The health registry wraps a registry and checks every node of every
+	service on an interval. A node which fails the check threshold times in
+	a row is unhealthy until it passes again. Unhealthy nodes are hidden from
+	GetService and Watch, or when marking, returned with "health" metadata.
+
+	Watchers are sent a result when the health of a node changes, a delete
+	or create when hiding nodes and an update when marking them.
+*/
+
+// Health is a registry which checks the health of nodes
+type Health interface {
+	registry.Registry
+	// Stop checking nodes
+	Stop()
+}
+
+// CheckFunc checks the health of a node, returning an error if it's unhealthy
+type CheckFunc func(*registry.Service, *registry.Node) error
+
+type Options struct {
+	// Check is the health check, by default a tcp connect
+	Check CheckFunc
+	// Interval between checks
+	Interval time.Duration
+	// Threshold is the number of failed checks before a node is unhealthy
+	Threshold int
+	// Mark unhealthy nodes with metadata rather than hiding them
+	Mark bool
+	// Concurrency is the max number of checks run at once
+	Concurrency int
+}
+
+type Option func(o *Options)
+
+type health struct {
+	registry.Registry
+	opts Options
+
+	sync.RWMutex
+	nodes    map[nodeKey]*status
+	watchers map[string]*healthWatcher
+	exit     chan bool
+}
+
+// nodeKey identifies a node of a service version
+type nodeKey struct {
+	service string
+	version string
+	id      string
+}
+
+// status is the health of a node
+type status struct {
+	failures int
+	healthy  bool
+}
+
+const (
+	// MetadataKey is the node metadata key set when marking nodes
+	MetadataKey = "health"
+
+	// Healthy is the metadata value of a healthy node
+	Healthy = "healthy"
+	// Unhealthy is the metadata value of an unhealthy node
+	Unhealthy = "unhealthy"
+)
+
+var (
+	// DefaultInterval is how often nodes are checked
+	DefaultInterval = time.Second * 10
+	// DefaultTimeout is the timeout of the default checks
+	DefaultTimeout = time.Second * 2
+	// DefaultThreshold is the number of failed checks before a node is unhealthy
+	DefaultThreshold = 2
+	// DefaultConcurrency is the max number of checks run at once
+	DefaultConcurrency = 16
+)
+
+// Check sets the health check e.g TCPCheck, HTTPCheck or RPCCheck
+func Check(fn CheckFunc) Option {
+	return func(o *Options) {
+		o.Check = fn
+	}
+}
+
+// Interval sets how often nodes are checked, values <= 0 keep the default
+func Interval(t time.Duration) Option {
+	return func(o *Options) {
+		o.Interval = t
+	}
+}
+
+// Threshold sets the number of failed checks before a node is unhealthy
+func Threshold(n int) Option {
+	return func(o *Options) {
+		o.Threshold = n
+	}
+}
+
+// Concurrency sets the max number of checks run at once
+func Concurrency(n int) Option {
+	return func(o *Options) {
+		o.Concurrency = n
+	}
+}
+
+// Mark returns unhealthy nodes with "health" metadata rather than hiding them
+func Mark() Option {
+	return func(o *Options) {
+		o.Mark = true
+	}
+}
+
+func (h *health) healthy(service *registry.Service, node *registry.Node) bool {
+	s, ok := h.nodes[nodeKey{service.Name, service.Version, node.Id}]
+	// nodes are healthy until checked
+	return !ok || s.healthy
+}
+
+// filter hides or marks the unhealthy nodes of the services, the read lock must be held
+func (h *health) filter(services []*registry.Service) []*registry.Service {
+	var filtered []*registry.Service
+
+	for _, service := range services {
+		s := copyService(service)
+		s.Nodes = nil
+
+		for _, node := range service.Nodes {
+			healthy := h.healthy(service, node)
+
+			if !healthy && !h.opts.Mark {
+				continue
+			}
+
+			n := copyNode(node)
+			if h.opts.Mark {
+				n.Metadata[MetadataKey] = Healthy
+				if !healthy {
+					n.Metadata[MetadataKey] = Unhealthy
+				}
+			}
+			s.Nodes = append(s.Nodes, n)
+		}
+
+		if len(s.Nodes) > 0 {
+			filtered = append(filtered, s)
+		}
+	}
+
+	return filtered
+}
+
+// check runs the check on every node of every service
+func (h *health) check() {
+	services, err := h.Registry.ListServices()
+	if err != nil {
+		log.Logf("health: error listing services: %v", err)
+		return
+	}
+
+	type result struct {
+		key     nodeKey
+		service *registry.Service
+		err     error
+	}
+
+	var mtx sync.Mutex
+	var wg sync.WaitGroup
+	var results []result
+
+	// limits the checks in flight
+	sem := make(chan bool, h.opts.Concurrency)
+	seen := make(map[string]bool)
+
+	for _, s := range services {
+		if seen[s.Name] {
+			continue
+		}
+		seen[s.Name] = true
+
+		rsp, err := h.Registry.GetService(s.Name)
+		if err != nil {
+			continue
+		}
+
+		for _, service := range rsp {
+			for _, node := range service.Nodes {
+				// the service with just the node
+				svc := copyService(service)
+				svc.Nodes = []*registry.Node{copyNode(node)}
+
+				sem <- true
+				wg.Add(1)
+				go func(svc *registry.Service) {
+					defer func() {
+						<-sem
+						wg.Done()
+					}()
+					err := h.opts.Check(svc, svc.Nodes[0])
+					mtx.Lock()
+					results = append(results, result{
+						key:     nodeKey{svc.Name, svc.Version, svc.Nodes[0].Id},
+						service: svc,
+						err:     err,
+					})
+					mtx.Unlock()
+				}(svc)
+			}
+		}
+	}
+
+	wg.Wait()
+
+	var changes []*registry.Result
+	checked := make(map[nodeKey]bool)
+
+	h.Lock()
+	for _, r := range results {
+		checked[r.key] = true
+
+		s, ok := h.nodes[r.key]
+		if !ok {
+			s = &status{healthy: true}
+			h.nodes[r.key] = s
+		}
+
+		if r.err == nil {
+			s.failures = 0
+		} else {
+			s.failures++
+		}
+
+		healthy := s.failures < h.opts.Threshold
+		if healthy == s.healthy {
+			continue
+		}
+		s.healthy = healthy
+
+		if !healthy {
+			log.Logf("health: node %s of %s is unhealthy: %v", r.key.id, r.key.service, r.err)
+		}
+
+		changes = append(changes, h.change(r.service, healthy))
+	}
+
+	// forget nodes which have been deregistered
+	for k := range h.nodes {
+		if !checked[k] {
+			delete(h.nodes, k)
+		}
+	}
+	h.Unlock()
+
+	for _, c := range changes {
+		h.notify(c)
+	}
+}
+
+// change returns the watch result for a change in the health of a node
+func (h *health) change(service *registry.Service, healthy bool) *registry.Result {
+	svc := copyService(service)
+
+	if h.opts.Mark {
+		svc.Nodes[0].Metadata[MetadataKey] = Healthy
+		if !healthy {
+			svc.Nodes[0].Metadata[MetadataKey] = Unhealthy
+		}
+		return &registry.Result{Action: "update", Service: svc}
+	}
+
+	if healthy {
+		return &registry.Result{Action: "create", Service: svc}
+	}
+	return &registry.Result{Action: "delete", Service: svc}
+}
+
+// notify sends the result to every watcher
+func (h *health) notify(r *registry.Result) {
+	var watchers []*healthWatcher
+
+	h.RLock()
+	for _, w := range h.watchers {
+		watchers = append(watchers, w)
+	}
+	h.RUnlock()
+
+	for _, w := range watchers {
+		select {
+		case w.next <- r:
+		case <-w.exit:
+		case <-h.exit:
+			return
+		}
+	}
+}
+
+func (h *health) run() {
+	t := time.NewTicker(h.opts.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-h.exit:
+			return
+		case <-t.C:
+			h.check()
+		}
+	}
+}
+
+func (h *health) GetService(name string) ([]*registry.Service, error) {
+	services, err := h.Registry.GetService(name)
+	if err != nil {
+		return nil, err
+	}
+
+	h.RLock()
+	services = h.filter(services)
+	h.RUnlock()
+
+	if len(services) == 0 {
+		return nil, registry.ErrNotFound
+	}
+
+	return services, nil
+}
+
+func (h *health) Watch() (registry.Watcher, error) {
+	w, err := h.Registry.Watch()
+	if err != nil {
+		return nil, err
+	}
+
+	hw := &healthWatcher{
+		id:      uuid.NewUUID().String(),
+		health:  h,
+		watcher: w,
+		next:    make(chan *registry.Result),
+		exit:    make(chan bool),
+	}
+
+	h.Lock()
+	h.watchers[hw.id] = hw
+	h.Unlock()
+
+	go hw.run()
+
+	return hw, nil
+}
+
+func (h *health) Stop() {
+	select {
+	case <-h.exit:
+		return
+	default:
+		close(h.exit)
+	}
+}
+
+func (h *health) String() string {
+	return "health"
+}
+
+// New returns a registry which checks the health of the nodes of r
+func New(r registry.Registry, opts ...Option) Health {
+	options := Options{
+		Interval:    DefaultInterval,
+		Threshold:   DefaultThreshold,
+		Concurrency: DefaultConcurrency,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if options.Check == nil {
+		options.Check = TCPCheck(DefaultTimeout)
+	}
+
+	if options.Interval <= 0 {
+		options.Interval = DefaultInterval
+	}
+
+	if options.Threshold < 1 {
+		options.Threshold = 1
+	}
+
+	if options.Concurrency < 1 {
+		options.Concurrency = 1
+	}
+
+	h := &health{
+		Registry: r,
+		opts:     options,
+		nodes:    make(map[nodeKey]*status),
+		watchers: make(map[string]*healthWatcher),
+		exit:     make(chan bool),
+	}
+
+	go h.run()
+
+	return h
+}
@@ -0,0 +1,260 @@
+package health
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-plugins/registry/memory"
+)
+
+// testCheck fails the check of unhealthy nodes
+type testCheck struct {
+	sync.Mutex
+	unhealthy map[string]bool
+}
+
+func (t *testCheck) set(id string, unhealthy bool) {
+	t.Lock()
+	t.unhealthy[id] = unhealthy
+	t.Unlock()
+}
+
+func (t *testCheck) check(s *registry.Service, n *registry.Node) error {
+	t.Lock()
+	defer t.Unlock()
+	if t.unhealthy[n.Id] {
+		return errors.New("unhealthy")
+	}
+	return nil
+}
+
+func testRegistry() registry.Registry {
+	m := memory.NewRegistry()
+	m.Register(&registry.Service{
+		Name:    "foo",
+		Version: "1.0.0",
+		Nodes: []*registry.Node{
+			{Id: "foo-1", Address: "localhost", Port: 8080},
+			{Id: "foo-2", Address: "localhost", Port: 8081},
+		},
+	})
+	return m
+}
+
+// next returns the next result of the watcher for the node
+func next(t *testing.T, w registry.Watcher, id string) *registry.Result {
+	results := make(chan *registry.Result)
+
+	go func() {
+		for {
+			r, err := w.Next()
+			if err != nil {
+				return
+			}
+			if len(r.Service.Nodes) == 1 && r.Service.Nodes[0].Id == id {
+				results <- r
+				return
+			}
+		}
+	}()
+
+	select {
+	case r := <-results:
+		return r
+	case <-time.After(time.Second):
+		t.Fatalf("Expected result for %s", id)
+	}
+	return nil
+}
+
+func nodeIds(services []*registry.Service) map[string]bool {
+	ids := make(map[string]bool)
+	for _, s := range services {
+		for _, n := range s.Nodes {
+			ids[n.Id] = true
+		}
+	}
+	return ids
+}
+
+func TestHealth(t *testing.T) {
+	c := &testCheck{unhealthy: map[string]bool{"foo-2": true}}
+
+	h := New(testRegistry(), Check(c.check), Interval(time.Millisecond*10), Threshold(2))
+	defer h.Stop()
+
+	w, err := h.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	// foo-2 is deleted once it fails the threshold
+	if r := next(t, w, "foo-2"); r.Action != "delete" {
+		t.Fatalf("Expected delete of foo-2, got %s", r.Action)
+	}
+
+	services, err := h.GetService("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ids := nodeIds(services); len(ids) != 1 || !ids["foo-1"] {
+		t.Fatalf("Expected only foo-1, got %v", ids)
+	}
+
+	// and created once healthy
+	c.set("foo-2", false)
+
+	if r := next(t, w, "foo-2"); r.Action != "create" {
+		t.Fatalf("Expected create of foo-2, got %s", r.Action)
+	}
+
+	services, err = h.GetService("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ids := nodeIds(services); len(ids) != 2 {
+		t.Fatalf("Expected foo-1 and foo-2, got %v", ids)
+	}
+}
+
+func TestHealthMark(t *testing.T) {
+	c := &testCheck{unhealthy: map[string]bool{"foo-2": true}}
+
+	h := New(testRegistry(), Check(c.check), Interval(time.Millisecond*10), Threshold(1), Mark())
+	defer h.Stop()
+
+	w, err := h.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	r := next(t, w, "foo-2")
+	if r.Action != "update" || r.Service.Nodes[0].Metadata[MetadataKey] != Unhealthy {
+		t.Fatalf("Expected update marking foo-2 unhealthy, got %s %v", r.Action, r.Service.Nodes[0].Metadata)
+	}
+
+	services, err := h.GetService("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"foo-1": Healthy, "foo-2": Unhealthy}
+
+	for _, n := range services[0].Nodes {
+		if n.Metadata[MetadataKey] != expected[n.Id] {
+			t.Fatalf("Expected %s to be %s, got %s", n.Id, expected[n.Id], n.Metadata[MetadataKey])
+		}
+	}
+}
+
+func TestHealthConcurrency(t *testing.T) {
+	m := memory.NewRegistry()
+	for i := 0; i < 20; i++ {
+		m.Register(&registry.Service{
+			Name:  "foo",
+			Nodes: []*registry.Node{{Id: "foo-" + strconv.Itoa(i), Address: "localhost", Port: 8080 + i}},
+		})
+	}
+
+	var mtx sync.Mutex
+	var running, max int
+
+	check := func(s *registry.Service, n *registry.Node) error {
+		mtx.Lock()
+		running++
+		if running > max {
+			max = running
+		}
+		mtx.Unlock()
+
+		time.Sleep(time.Millisecond * 10)
+
+		mtx.Lock()
+		running--
+		mtx.Unlock()
+		return nil
+	}
+
+	h := New(m, Check(check), Interval(time.Hour), Concurrency(3))
+	defer h.Stop()
+
+	h.(*health).check()
+
+	if max == 0 || max > 3 {
+		t.Fatalf("Expected at most 3 checks at once, got %d", max)
+	}
+
+	if s := h.String(); s != "health" {
+		t.Fatalf("Expected health, got %s", s)
+	}
+}
+
+func TestInterval(t *testing.T) {
+	for _, d := range []time.Duration{0, -time.Second} {
+		h := New(testRegistry(), Interval(d))
+		h.Stop()
+
+		if i := h.(*health).opts.Interval; i != DefaultInterval {
+			t.Fatalf("Expected interval %v for %v, got %v", DefaultInterval, d, i)
+		}
+	}
+}
+
+func testNode(t *testing.T, addr string) *registry.Node {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := strconv.Atoi(port)
+	return &registry.Node{Id: "test", Address: host, Port: p}
+}
+
+func TestTCPCheck(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := TCPCheck(time.Second)
+	node := testNode(t, l.Addr().String())
+
+	if err := check(&registry.Service{Name: "test"}, node); err != nil {
+		t.Fatalf("Expected check to pass, got %v", err)
+	}
+
+	l.Close()
+
+	if err := check(&registry.Service{Name: "test"}, node); err == nil {
+		t.Fatal("Expected check to fail once closed")
+	}
+}
+
+func TestHTTPCheck(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer s.Close()
+
+	node := testNode(t, s.Listener.Addr().String())
+
+	if err := HTTPCheck("/health", time.Second)(&registry.Service{Name: "test"}, node); err != nil {
+		t.Fatalf("Expected check to pass, got %v", err)
+	}
+
+	if err := HTTPCheck("/unhealthy", time.Second)(&registry.Service{Name: "test"}, node); err == nil {
+		t.Fatal("Expected check to fail")
+	}
+}
@@ -0,0 +1,29 @@
+package health
+
+import (
+	"github.com/micro/go-micro/registry"
+)
+
+func copyNode(n *registry.Node) *registry.Node {
+	node := new(registry.Node)
+	*node = *n
+
+	node.Metadata = make(map[string]string, len(n.Metadata))
+	for k, v := range n.Metadata {
+		node.Metadata[k] = v
+	}
+
+	return node
+}
+
+func copyService(s *registry.Service) *registry.Service {
+	service := new(registry.Service)
+	*service = *s
+
+	service.Nodes = make([]*registry.Node, len(s.Nodes))
+	for i, node := range s.Nodes {
+		service.Nodes[i] = copyNode(node)
+	}
+
+	return service
+}
@@ -0,0 +1,72 @@
+package health
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/micro/go-micro/registry"
+)
+
+// healthWatcher merges the results of the registry's
+// watcher with changes in the health of nodes
+type healthWatcher struct {
+	id      string
+	health  *health
+	watcher registry.Watcher
+	next    chan *registry.Result
+	exit    chan bool
+	once    sync.Once
+}
+
+func (w *healthWatcher) run() {
+	for {
+		r, err := w.watcher.Next()
+		if err != nil {
+			w.Stop()
+			return
+		}
+
+		if r.Service == nil {
+			continue
+		}
+
+		// hide or mark unhealthy nodes of created or updated services
+		if r.Action != "delete" {
+			w.health.RLock()
+			services := w.health.filter([]*registry.Service{r.Service})
+			w.health.RUnlock()
+
+			if len(services) == 0 {
+				continue
+			}
+
+			r = &registry.Result{Action: r.Action, Service: services[0]}
+		}
+
+		select {
+		case w.next <- r:
+		case <-w.exit:
+			return
+		}
+	}
+}
+
+func (w *healthWatcher) Next() (*registry.Result, error) {
+	select {
+	case r := <-w.next:
+		return r, nil
+	case <-w.exit:
+		return nil, errors.New("watcher stopped")
+	}
+}
+
+func (w *healthWatcher) Stop() {
+	w.once.Do(func() {
+		w.health.Lock()
+		delete(w.health.watchers, w.id)
+		w.health.Unlock()
+
+		close(w.exit)
+		w.watcher.Stop()
+	})
+}
@@ -49,6 +49,20 @@ var tests = []testcase{
 		Method: "GET",
 		URI:    "/api/v1/namespaces/default/pods/?labelSelectors=foo%3Dbar",
 	},
+	testcase{
+		ReqFn: func(opts *Options) *Request {
+			return NewRequest(opts).Get().Group("discovery.k8s.io/v1").Resource("endpointslices").Namespace("test")
+		},
+		Method: "GET",
+		URI:    "/apis/discovery.k8s.io/v1/namespaces/test/endpointslices/",
+	},
+	testcase{
+		ReqFn: func(opts *Options) *Request {
+			return NewRequest(opts).Get().Resource("pods").Namespace("")
+		},
+		Method: "GET",
+		URI:    "/api/v1/pods/",
+	},
 	testcase{
 		ReqFn: func(opts *Options) *Request {
 			return NewRequest(opts).Post().Resource("services").Name("foo").Body(map[string]string{"foo": "bar"})
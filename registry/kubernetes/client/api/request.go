@@ -19,6 +19,7 @@ type Request struct {
 	method    string
 	host      string
 	namespace string
+	group     string
 
 	resource     string
 	resourceName *string
@@ -66,12 +67,20 @@ func (r *Request) Delete() *Request {
 	return r.verb("DELETE")
 }
 
-// Namespace is to set the namespace to operate on
+// Namespace is to set the namespace to operate on,
+// an empty namespace operates on all namespaces
 func (r *Request) Namespace(s string) *Request {
 	r.namespace = s
 	return r
 }
 
+// Group sets the API group and version of the resource,
+// such as "discovery.k8s.io/v1". The core API is used if unset.
+func (r *Request) Group(s string) *Request {
+	r.group = s
+	return r
+}
+
 // Resource is the type of resource the operation is
 // for, such as "services", "endpoints" or "pods"
 func (r *Request) Resource(s string) *Request {
@@ -115,7 +124,15 @@ func (r *Request) SetHeader(key, value string) *Request {
 
 // request builds the http.Request from the options
 func (r *Request) request() (*http.Request, error) {
-	url := fmt.Sprintf("%s/api/v1/namespaces/%s/%s/", r.host, r.namespace, r.resource)
+	api := "api/v1"
+	if len(r.group) > 0 {
+		api = "apis/" + r.group
+	}
+
+	url := fmt.Sprintf("%s/%s/namespaces/%s/%s/", r.host, api, r.namespace, r.resource)
+	if len(r.namespace) == 0 {
+		url = fmt.Sprintf("%s/%s/%s/", r.host, api, r.resource)
+	}
 
 	// append resourceName if it is present
 	if r.resourceName != nil {
@@ -16,6 +16,9 @@ import (
 var (
 	serviceAccountPath = "/var/run/secrets/kubernetes.io/serviceaccount"
 
+	// api group of endpoint slices
+	discoveryGroup = "discovery.k8s.io/v1"
+
 	ErrReadNamespace = errors.New("Could not read namespace from service account secret")
 )
 
@@ -43,6 +46,18 @@ func (c *client) WatchPods(labels map[string]string) (watch.Watch, error) {
 	return api.NewRequest(c.opts).Get().Resource("pods").Params(&api.Params{LabelSelector: labels}).Watch()
 }
 
+// ListEndpointSlices ...
+func (c *client) ListEndpointSlices(labels map[string]string) (*EndpointSliceList, error) {
+	var slices EndpointSliceList
+	err := api.NewRequest(c.opts).Get().Group(discoveryGroup).Resource("endpointslices").Params(&api.Params{LabelSelector: labels}).Do().Into(&slices)
+	return &slices, err
+}
+
+// WatchEndpointSlices ...
+func (c *client) WatchEndpointSlices(labels map[string]string) (watch.Watch, error) {
+	return api.NewRequest(c.opts).Get().Group(discoveryGroup).Resource("endpointslices").Params(&api.Params{LabelSelector: labels}).Watch()
+}
+
 func detectNamespace() (string, error) {
 	nsPath := path.Join(serviceAccountPath, "namespace")
 
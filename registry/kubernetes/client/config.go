@@ -0,0 +1,200 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/ghodss/yaml"
+	"github.com/micro/go-plugins/registry/kubernetes/client/api"
+)
+
+// Config is the connection to a cluster, as read from a kubeconfig file
+type Config struct {
+	// Cluster is the name of the cluster
+	Cluster     string
+	Host        string
+	Namespace   string
+	BearerToken string
+	TLSConfig   *tls.Config
+}
+
+// kubeConfig is the subset of the kubeconfig file format used
+type kubeConfig struct {
+	CurrentContext string `json:"current-context"`
+	Clusters       []struct {
+		Name    string `json:"name"`
+		Cluster struct {
+			Server                   string `json:"server"`
+			CertificateAuthority     string `json:"certificate-authority"`
+			CertificateAuthorityData []byte `json:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify"`
+		} `json:"cluster"`
+	} `json:"clusters"`
+	Users []struct {
+		Name string `json:"name"`
+		User struct {
+			Token                 string `json:"token"`
+			ClientCertificate     string `json:"client-certificate"`
+			ClientCertificateData []byte `json:"client-certificate-data"`
+			ClientKey             string `json:"client-key"`
+			ClientKeyData         []byte `json:"client-key-data"`
+		} `json:"user"`
+	} `json:"users"`
+	Contexts []struct {
+		Name    string `json:"name"`
+		Context struct {
+			Cluster   string `json:"cluster"`
+			User      string `json:"user"`
+			Namespace string `json:"namespace"`
+		} `json:"context"`
+	} `json:"contexts"`
+}
+
+// readFile returns data if set, otherwise the contents of the file
+func readFile(data []byte, file string) ([]byte, error) {
+	if len(data) > 0 || len(file) == 0 {
+		return data, nil
+	}
+	return ioutil.ReadFile(file)
+}
+
+// LoadConfig reads the current context of a kubeconfig file
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var kc kubeConfig
+	if err := yaml.Unmarshal(b, &kc); err != nil {
+		return nil, err
+	}
+
+	config := &Config{
+		Namespace: "default",
+		TLSConfig: &tls.Config{},
+	}
+
+	var user string
+	for _, c := range kc.Contexts {
+		if c.Name != kc.CurrentContext {
+			continue
+		}
+		config.Cluster = c.Context.Cluster
+		user = c.Context.User
+		if len(c.Context.Namespace) > 0 {
+			config.Namespace = c.Context.Namespace
+		}
+	}
+
+	if len(config.Cluster) == 0 {
+		return nil, fmt.Errorf("context %q not found in %s", kc.CurrentContext, path)
+	}
+
+	for _, c := range kc.Clusters {
+		if c.Name != config.Cluster {
+			continue
+		}
+
+		config.Host = c.Cluster.Server
+		config.TLSConfig.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify
+
+		ca, err := readFile(c.Cluster.CertificateAuthorityData, c.Cluster.CertificateAuthority)
+		if err != nil {
+			return nil, err
+		}
+		if len(ca) > 0 {
+			certs, err := CertsFromPEM(ca)
+			if err != nil {
+				return nil, err
+			}
+			pool := x509.NewCertPool()
+			for _, cert := range certs {
+				pool.AddCert(cert)
+			}
+			config.TLSConfig.RootCAs = pool
+		}
+	}
+
+	if len(config.Host) == 0 {
+		return nil, fmt.Errorf("cluster %q not found in %s", config.Cluster, path)
+	}
+
+	for _, u := range kc.Users {
+		if u.Name != user {
+			continue
+		}
+
+		config.BearerToken = u.User.Token
+
+		crt, err := readFile(u.User.ClientCertificateData, u.User.ClientCertificate)
+		if err != nil {
+			return nil, err
+		}
+		key, err := readFile(u.User.ClientKeyData, u.User.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		if len(crt) == 0 || len(key) == 0 {
+			continue
+		}
+
+		cert, err := tls.X509KeyPair(crt, key)
+		if err != nil {
+			return nil, err
+		}
+		config.TLSConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// NewClientByConfig sets up a client from a config
+func NewClientByConfig(config *Config) Kubernetes {
+	tlsConfig := config.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+
+	c := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:    tlsConfig,
+			DisableCompression: true,
+		},
+	}
+
+	opts := &api.Options{
+		Client:    c,
+		Host:      config.Host,
+		Namespace: config.Namespace,
+	}
+
+	if len(config.BearerToken) > 0 {
+		t := config.BearerToken
+		opts.BearerToken = &t
+	}
+
+	return &client{
+		opts: opts,
+	}
+}
+
+// WithNamespace returns a copy of a client which operates on
+// the namespace, an empty namespace operates on all namespaces
+func WithNamespace(k Kubernetes, namespace string) (Kubernetes, error) {
+	c, ok := k.(*client)
+	if !ok {
+		return nil, errors.New("client does not support namespaces")
+	}
+
+	opts := *c.opts
+	opts.Namespace = namespace
+
+	return &client{
+		opts: &opts,
+	}, nil
+}
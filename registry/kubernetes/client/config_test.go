@@ -0,0 +1,90 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testKubeConfig = `
+apiVersion: v1
+kind: Config
+current-context: west
+clusters:
+- name: east-cluster
+  cluster:
+    server: https://east.example.com
+- name: west-cluster
+  cluster:
+    server: https://west.example.com
+    insecure-skip-tls-verify: true
+users:
+- name: admin
+  user:
+    token: secret
+contexts:
+- name: east
+  context:
+    cluster: east-cluster
+    user: admin
+- name: west
+  context:
+    cluster: west-cluster
+    user: admin
+    namespace: micro
+`
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config")
+	if err := ioutil.WriteFile(path, []byte(testKubeConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("did not expect LoadConfig to fail %v", err)
+	}
+
+	if config.Cluster != "west-cluster" {
+		t.Fatalf("Expected cluster west-cluster, got %s", config.Cluster)
+	}
+	if config.Host != "https://west.example.com" {
+		t.Fatalf("Expected host https://west.example.com, got %s", config.Host)
+	}
+	if config.Namespace != "micro" {
+		t.Fatalf("Expected namespace micro, got %s", config.Namespace)
+	}
+	if config.BearerToken != "secret" {
+		t.Fatalf("Expected token secret, got %s", config.BearerToken)
+	}
+	if !config.TLSConfig.InsecureSkipVerify {
+		t.Fatal("Expected tls verification to be skipped")
+	}
+
+	if _, err := LoadConfig(filepath.Join(dir, "missing")); err == nil {
+		t.Fatal("Expected error for missing kubeconfig")
+	}
+}
+
+func TestWithNamespace(t *testing.T) {
+	c := NewClientByHost("https://localhost")
+
+	k, err := WithNamespace(c, "")
+	if err != nil {
+		t.Fatalf("did not expect WithNamespace to fail %v", err)
+	}
+
+	if ns := k.(*client).opts.Namespace; ns != "" {
+		t.Fatalf("Expected all namespaces, got %s", ns)
+	}
+	if ns := c.(*client).opts.Namespace; ns != "default" {
+		t.Fatalf("Expected original client to be unchanged, got %s", ns)
+	}
+}
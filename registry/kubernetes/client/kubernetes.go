@@ -7,6 +7,8 @@ type Kubernetes interface {
 	ListPods(labels map[string]string) (*PodList, error)
 	UpdatePod(podName string, pod *Pod) (*Pod, error)
 	WatchPods(labels map[string]string) (watch.Watch, error)
+	ListEndpointSlices(labels map[string]string) (*EndpointSliceList, error)
+	WatchEndpointSlices(labels map[string]string) (watch.Watch, error)
 }
 
 // PodList ...
@@ -23,6 +25,7 @@ type Pod struct {
 // Meta ...
 type Meta struct {
 	Name        string             `json:"name,omitempty"`
+	Namespace   string             `json:"namespace,omitempty"`
 	Labels      map[string]*string `json:"labels,omitempty"`
 	Annotations map[string]*string `json:"annotations,omitempty"`
 }
@@ -32,3 +35,47 @@ type Status struct {
 	PodIP string `json:"podIP"`
 	Phase string `json:"phase"`
 }
+
+// EndpointSliceList ...
+type EndpointSliceList struct {
+	Items []EndpointSlice `json:"items"`
+}
+
+// EndpointSlice is a subset of the endpoints of a service
+type EndpointSlice struct {
+	Metadata    *Meta          `json:"metadata"`
+	AddressType string         `json:"addressType"`
+	Endpoints   []Endpoint     `json:"endpoints"`
+	Ports       []EndpointPort `json:"ports"`
+}
+
+// Endpoint ...
+type Endpoint struct {
+	Addresses  []string            `json:"addresses"`
+	Conditions *EndpointConditions `json:"conditions,omitempty"`
+	Hostname   *string             `json:"hostname,omitempty"`
+	NodeName   *string             `json:"nodeName,omitempty"`
+	Zone       *string             `json:"zone,omitempty"`
+	TargetRef  *ObjectReference    `json:"targetRef,omitempty"`
+}
+
+// EndpointConditions ...
+type EndpointConditions struct {
+	Ready       *bool `json:"ready,omitempty"`
+	Serving     *bool `json:"serving,omitempty"`
+	Terminating *bool `json:"terminating,omitempty"`
+}
+
+// EndpointPort ...
+type EndpointPort struct {
+	Name     *string `json:"name,omitempty"`
+	Protocol *string `json:"protocol,omitempty"`
+	Port     *int    `json:"port,omitempty"`
+}
+
+// ObjectReference ...
+type ObjectReference struct {
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
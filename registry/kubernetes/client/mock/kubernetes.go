@@ -15,6 +15,13 @@ type Client struct {
 	Pods     map[string]*client.Pod
 	events   chan watch.Event
 	watchers []*mockWatcher
+
+	// guards watchers and sliceWatchers
+	wmu sync.Mutex
+
+	EndpointSlices map[string]*client.EndpointSlice
+	sliceEvents    chan watch.Event
+	sliceWatchers  []*mockWatcher
 }
 
 // UpdatePod ...
@@ -52,20 +59,111 @@ func (m *Client) ListPods(labels map[string]string) (*client.PodList, error) {
 
 // WatchPods ...
 func (m *Client) WatchPods(labels map[string]string) (watch.Watch, error) {
+	return m.watch(&m.watchers), nil
+}
+
+// watch adds a watcher to the list, which is removed when stopped
+func (m *Client) watch(watchers *[]*mockWatcher) *mockWatcher {
 	w := &mockWatcher{
 		results: make(chan watch.Event),
 		stop:    make(chan bool),
 	}
 
-	i := len(m.watchers) // length of watchers is current index
-	m.watchers = append(m.watchers, w)
+	m.wmu.Lock()
+	*watchers = append(*watchers, w)
+	m.wmu.Unlock()
 
 	go func() {
 		<-w.stop
-		m.watchers = append(m.watchers[:i], m.watchers[i+1:]...)
+		m.wmu.Lock()
+		for i, mw := range *watchers {
+			if mw == w {
+				*watchers = append((*watchers)[:i], (*watchers)[i+1:]...)
+				break
+			}
+		}
+		// nothing can be sending once removed
+		close(w.results)
+		m.wmu.Unlock()
 	}()
 
-	return w, nil
+	return w
+}
+
+// broadcast sends an event to every watcher in the list
+func (m *Client) broadcast(watchers *[]*mockWatcher, e watch.Event) {
+	m.wmu.Lock()
+	defer m.wmu.Unlock()
+
+	for _, w := range *watchers {
+		select {
+		case w.results <- e:
+		case <-w.stop:
+		}
+	}
+}
+
+// ListEndpointSlices ...
+func (m *Client) ListEndpointSlices(labels map[string]string) (*client.EndpointSliceList, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	var slices []client.EndpointSlice
+
+	for _, v := range m.EndpointSlices {
+		if labelFilterMatch(v.Metadata.Labels, labels) {
+			slices = append(slices, *v)
+		}
+	}
+	return &client.EndpointSliceList{
+		Items: slices,
+	}, nil
+}
+
+// WatchEndpointSlices ...
+func (m *Client) WatchEndpointSlices(labels map[string]string) (watch.Watch, error) {
+	return m.watch(&m.sliceWatchers), nil
+}
+
+// UpdateEndpointSlice adds or modifies an endpoint slice
+// and sends the event to watchers
+func (m *Client) UpdateEndpointSlice(slice *client.EndpointSlice) {
+	m.Lock()
+	_, ok := m.EndpointSlices[slice.Metadata.Name]
+	m.EndpointSlices[slice.Metadata.Name] = slice
+	m.Unlock()
+
+	eventType := watch.Added
+	if ok {
+		eventType = watch.Modified
+	}
+
+	sstr, _ := json.Marshal(slice)
+
+	m.sliceEvents <- watch.Event{
+		Type:   eventType,
+		Object: json.RawMessage(sstr),
+	}
+}
+
+// DeleteEndpointSlice deletes an endpoint slice
+// and sends the event to watchers
+func (m *Client) DeleteEndpointSlice(name string) {
+	m.Lock()
+	slice, ok := m.EndpointSlices[name]
+	delete(m.EndpointSlices, name)
+	m.Unlock()
+
+	if !ok {
+		return
+	}
+
+	sstr, _ := json.Marshal(slice)
+
+	m.sliceEvents <- watch.Event{
+		Type:   watch.Deleted,
+		Object: json.RawMessage(sstr),
+	}
 }
 
 // newClient ...
@@ -76,16 +174,23 @@ func newClient() client.Kubernetes {
 // NewClient ...
 func NewClient() *Client {
 	c := &Client{
-		Pods:   make(map[string]*client.Pod),
-		events: make(chan watch.Event),
+		Pods:           make(map[string]*client.Pod),
+		events:         make(chan watch.Event),
+		EndpointSlices: make(map[string]*client.EndpointSlice),
+		sliceEvents:    make(chan watch.Event),
 	}
 
 	// broadcast events to watchers
 	go func() {
 		for e := range c.events {
-			for _, w := range c.watchers {
-				w.results <- e
-			}
+			c.broadcast(&c.watchers, e)
+		}
+	}()
+
+	// broadcast endpoint slice events to watchers
+	go func() {
+		for e := range c.sliceEvents {
+			c.broadcast(&c.sliceWatchers, e)
 		}
 	}()
 
@@ -105,4 +210,15 @@ func Teardown(c *Client) {
 	}
 
 	c.Pods = make(map[string]*client.Pod)
+
+	var slices []string
+	c.Lock()
+	for name := range c.EndpointSlices {
+		slices = append(slices, name)
+	}
+	c.Unlock()
+
+	for _, name := range slices {
+		c.DeleteEndpointSlice(name)
+	}
 }
@@ -15,14 +15,14 @@ func (w *mockWatcher) ResultChan() <-chan watch.Event {
 	return w.results
 }
 
-// Stop closes any channels
+// Stop closes the stop channel, results is
+// closed once the watcher is removed
 func (w *mockWatcher) Stop() {
 	select {
 	case <-w.stop:
 		return
 	default:
 		close(w.stop)
-		close(w.results)
 	}
 }
 
@@ -0,0 +1,200 @@
+package kubernetes
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-plugins/registry/kubernetes/client"
+)
+
+/*
+	Services can be discovered across namespaces and clusters. The registry
+	holds a client per namespace of each cluster and merges the results into
+	one view. When namespaces or clusters are configured nodes are given
+	"namespace" and "cluster" metadata so callers can tell where they are
+	running. Services are only registered in the namespace and cluster the
+	registry is running in.
+*/
+
+// kclient is a client for a namespace of a cluster, an empty namespace is
+// all namespaces of the cluster. The cluster is only set for multiple clusters.
+type kclient struct {
+	client.Kubernetes
+	cluster   string
+	namespace string
+	// namespaces were configured
+	namespaced bool
+}
+
+// setMetadata records where the nodes of a service were discovered
+// when services are discovered across namespaces or clusters
+func (k *kclient) setMetadata(svc *registry.Service, namespace string) {
+	if !k.namespaced && len(k.cluster) == 0 {
+		return
+	}
+
+	if len(namespace) == 0 {
+		namespace = k.namespace
+	}
+
+	for _, node := range svc.Nodes {
+		if node.Metadata == nil {
+			node.Metadata = make(map[string]string)
+		}
+		if len(namespace) > 0 {
+			node.Metadata["namespace"] = namespace
+		}
+		if len(k.cluster) > 0 {
+			node.Metadata["cluster"] = k.cluster
+		}
+	}
+}
+
+// metaKey is the key of an object in a watcher cache,
+// names are only unique within a namespace
+func metaKey(m *client.Meta) string {
+	return m.Namespace + "/" + m.Name
+}
+
+// discovery returns the clients services are discovered with
+func (c *kregistry) discovery() []*kclient {
+	if len(c.clients) > 0 {
+		return c.clients
+	}
+	return []*kclient{{Kubernetes: c.client}}
+}
+
+// eachClient calls fn for every discovery client. An error is only
+// returned if every client fails, so one unavailable cluster does
+// not hide the services of the others.
+func (c *kregistry) eachClient(fn func(*kclient) error) error {
+	var err error
+	var ok bool
+
+	for _, k := range c.discovery() {
+		if e := fn(k); e != nil {
+			err = e
+			continue
+		}
+		ok = true
+	}
+
+	if !ok {
+		return err
+	}
+	return nil
+}
+
+// namespaceClients returns a discovery client for each namespace of a cluster
+func namespaceClients(cluster string, c client.Kubernetes, namespaces []string) ([]*kclient, error) {
+	if len(namespaces) == 0 {
+		return []*kclient{{Kubernetes: c, cluster: cluster}}, nil
+	}
+
+	var clients []*kclient
+	for _, ns := range namespaces {
+		nc, err := client.WithNamespace(c, ns)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, &kclient{
+			Kubernetes: nc,
+			cluster:    cluster,
+			namespace:  ns,
+			namespaced: true,
+		})
+	}
+	return clients, nil
+}
+
+// discoveryClients returns the clusters which can be discovered from, in
+// order, and their clients. A cluster whose clients fail is skipped and
+// the last error returned.
+func discoveryClients(clusters []string, clients map[string]client.Kubernetes, namespaces []string) ([]string, []*kclient, error) {
+	// nodes are only labelled with their cluster when there are many
+	multi := len(clusters) > 1
+
+	var usable []string
+	var discovery []*kclient
+	var err error
+
+	for _, cluster := range clusters {
+		name := cluster
+		if !multi {
+			name = ""
+		}
+
+		kc, cerr := namespaceClients(name, clients[cluster], namespaces)
+		if cerr != nil {
+			log.Logf("kubernetes: skipping cluster %s: %v", cluster, cerr)
+			err = cerr
+			continue
+		}
+
+		usable = append(usable, cluster)
+		discovery = append(discovery, kc...)
+	}
+
+	return usable, discovery, err
+}
+
+// multiWatcher merges the watchers of every discovery client
+type multiWatcher struct {
+	watchers []registry.Watcher
+	next     chan *registry.Result
+	exit     chan bool
+	once     sync.Once
+}
+
+func newMultiWatcher(watchers []registry.Watcher) registry.Watcher {
+	m := &multiWatcher{
+		watchers: watchers,
+		next:     make(chan *registry.Result),
+		exit:     make(chan bool),
+	}
+
+	for _, w := range watchers {
+		go m.run(w)
+	}
+
+	return m
+}
+
+func (m *multiWatcher) run(w registry.Watcher) {
+	for {
+		r, err := w.Next()
+		if err != nil {
+			// stop everything so the caller watches again
+			m.Stop()
+			return
+		}
+
+		select {
+		case m.next <- r:
+		case <-m.exit:
+			return
+		}
+	}
+}
+
+// Next will block until a new result comes in
+func (m *multiWatcher) Next() (*registry.Result, error) {
+	select {
+	case r := <-m.next:
+		return r, nil
+	case <-m.exit:
+		return nil, errors.New("watcher stopped")
+	}
+}
+
+// Stop will stop every watcher
+func (m *multiWatcher) Stop() {
+	m.once.Do(func() {
+		close(m.exit)
+		for _, w := range m.watchers {
+			w.Stop()
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-plugins/registry/kubernetes/client"
+	"github.com/micro/go-plugins/registry/kubernetes/client/mock"
+)
+
+// adds a running pod to a cluster, with the service annotation if set
+func setupClusterPod(m *mock.Client, name, namespace, ip string, svc *registry.Service) *client.Pod {
+	p := &client.Pod{
+		Metadata: &client.Meta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      make(map[string]*string),
+			Annotations: make(map[string]*string),
+		},
+		Status: &client.Status{
+			PodIP: ip,
+			Phase: podRunning,
+		},
+	}
+
+	if svc != nil {
+		p.Metadata.Labels = servicePod(svc).Metadata.Labels
+		p.Metadata.Annotations = servicePod(svc).Metadata.Annotations
+	}
+
+	m.Pods[name] = p
+	return p
+}
+
+// builds the pod patch which registers a service
+func servicePod(svc *registry.Service) *client.Pod {
+	b, _ := json.Marshal(svc)
+	s := string(b)
+
+	return &client.Pod{
+		Metadata: &client.Meta{
+			Labels: map[string]*string{
+				labelTypeKey: &labelTypeValueService,
+				svcSelectorPrefix + serviceName(svc.Name): &svcSelectorValue,
+			},
+			Annotations: map[string]*string{
+				annotationServiceKeyPrefix + serviceName(svc.Name): &s,
+			},
+		},
+	}
+}
+
+func clusterService(id, address string) *registry.Service {
+	return &registry.Service{
+		Name:    "foo.service",
+		Version: "1",
+		Nodes: []*registry.Node{{
+			Id:      id,
+			Address: address,
+			Port:    80,
+		}},
+	}
+}
+
+func TestMultiCluster(t *testing.T) {
+	east := mock.NewClient()
+	west := mock.NewClient()
+	defer mock.Teardown(east)
+	defer mock.Teardown(west)
+
+	r := &kregistry{
+		client:  east,
+		timeout: time.Second * 1,
+		clients: []*kclient{
+			{Kubernetes: east, cluster: "east", namespace: "micro"},
+			// all namespaces
+			{Kubernetes: west, cluster: "west"},
+		},
+	}
+
+	setupClusterPod(east, "pod-1", "", "10.0.0.1", clusterService("foo-1", "10.0.0.1"))
+	setupClusterPod(west, "pod-1", "prod", "10.1.0.1", clusterService("foo-2", "10.1.0.1"))
+
+	services, err := r.GetService("foo.service")
+	if err != nil {
+		t.Fatalf("did not expect GetService to fail %v", err)
+	}
+
+	if len(services) != 1 || len(services[0].Nodes) != 2 {
+		t.Fatalf("expected 1 service with 2 nodes, got %+v", services)
+	}
+
+	expected := map[string]map[string]string{
+		"foo-1": {"cluster": "east", "namespace": "micro"},
+		"foo-2": {"cluster": "west", "namespace": "prod"},
+	}
+
+	for _, node := range services[0].Nodes {
+		md, ok := expected[node.Id]
+		if !ok {
+			t.Fatalf("unexpected node %s", node.Id)
+		}
+		for k, v := range md {
+			if node.Metadata[k] != v {
+				t.Fatalf("expected node %s to have %s %s, got %v", node.Id, k, v, node.Metadata)
+			}
+		}
+	}
+
+	list, err := r.ListServices()
+	if err != nil {
+		t.Fatalf("did not expect ListServices to fail %v", err)
+	}
+	if len(list) != 1 || list[0].Name != "foo.service" {
+		t.Fatalf("expected foo.service to be listed once, got %+v", list)
+	}
+
+	w, err := r.Watch()
+	if err != nil {
+		t.Fatalf("did not expect Watch to fail %v", err)
+	}
+	defer w.Stop()
+
+	// register a new pod in the west cluster
+	setupClusterPod(west, "pod-2", "test", "10.1.0.2", nil)
+	go west.UpdatePod("pod-2", servicePod(clusterService("foo-3", "10.1.0.2")))
+
+	res, err := w.Next()
+	if err != nil {
+		t.Fatalf("did not expect Next to fail %v", err)
+	}
+	if res.Action != "create" || len(res.Service.Nodes) != 1 {
+		t.Fatalf("expected create of 1 node, got %s %+v", res.Action, res.Service)
+	}
+
+	md := res.Service.Nodes[0].Metadata
+	if md["cluster"] != "west" || md["namespace"] != "test" {
+		t.Fatalf("expected node from west cluster in test namespace, got %v", md)
+	}
+}
+
+func TestSingleCluster(t *testing.T) {
+	// only the first address is used
+	r := NewRegistry(registry.Addrs("http://east:8080", "http://west:8080")).(*kregistry)
+
+	if r.err != nil {
+		t.Fatalf("did not expect NewRegistry to fail %v", r.err)
+	}
+	if len(r.clients) != 1 || len(r.clients[0].cluster) != 0 {
+		t.Fatalf("expected 1 client without a cluster, got %+v", r.clients)
+	}
+
+	// nodes aren't given metadata
+	svc := clusterService("foo-1", "10.0.0.1")
+	r.clients[0].setMetadata(svc, "default")
+	if svc.Nodes[0].Metadata != nil {
+		t.Fatalf("expected no metadata, got %v", svc.Nodes[0].Metadata)
+	}
+
+	r = NewRegistry(
+		registry.Addrs("http://east:8080", "http://west:8080"),
+		Clusters("http://west:8080", "http://south:8080"),
+	).(*kregistry)
+
+	var clusters []string
+	for _, k := range r.clients {
+		clusters = append(clusters, k.cluster)
+	}
+
+	if len(clusters) != 3 || clusters[0] != "http://east:8080" || clusters[1] != "http://west:8080" || clusters[2] != "http://south:8080" {
+		t.Fatalf("expected the first address and the configured clusters, got %v", clusters)
+	}
+}
+
+func TestNamespaceError(t *testing.T) {
+	c := mock.NewClient()
+	defer mock.Teardown(c)
+
+	if _, err := namespaceClients("", c, []string{"default"}); err == nil {
+		t.Fatal("expected namespaces to be unsupported by the mock client")
+	}
+
+	r := NewRegistry(KubeConfig("/does/not/exist"))
+
+	if _, err := r.GetService("foo.service"); err == nil {
+		t.Fatal("expected GetService to fail for a missing kubeconfig")
+	}
+	if _, err := r.Watch(); err == nil {
+		t.Fatal("expected Watch to fail for a missing kubeconfig")
+	}
+}
+
+func TestSkipCluster(t *testing.T) {
+	m := mock.NewClient()
+	defer mock.Teardown(m)
+
+	clients := map[string]client.Kubernetes{
+		"east": client.NewClientByHost("http://east:8080"),
+		"mock": m,
+		"west": client.NewClientByHost("http://west:8080"),
+	}
+
+	// the mock client doesn't support namespaces so is skipped
+	clusters, discovery, err := discoveryClients([]string{"mock", "east", "west"}, clients, []string{"default"})
+	if err == nil {
+		t.Fatal("expected the mock cluster to fail")
+	}
+	if len(clusters) != 2 || clusters[0] != "east" || clusters[1] != "west" {
+		t.Fatalf("expected the later clusters to be kept, got %v", clusters)
+	}
+	if len(discovery) != 2 || discovery[0].cluster != "east" || discovery[1].cluster != "west" {
+		t.Fatalf("expected clients for east and west, got %+v", discovery)
+	}
+
+	// a kubeconfig which fails to load is skipped
+	r := NewRegistry(registry.Addrs("http://east:8080"), KubeConfig("/does/not/exist")).(*kregistry)
+
+	if r.err != nil {
+		t.Fatalf("did not expect NewRegistry to fail %v", r.err)
+	}
+	if len(r.clients) != 1 {
+		t.Fatalf("expected 1 client, got %+v", r.clients)
+	}
+}
@@ -0,0 +1,375 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-plugins/registry/kubernetes/client"
+	"github.com/micro/go-plugins/registry/kubernetes/client/watch"
+)
+
+/*
+	In endpoint slice mode services are resolved from the EndpointSlices of
+	Kubernetes Services rather than pod annotations. A Kubernetes Service is
+	made discoverable by labelling it with micro.mu/type=service and
+	micro.mu/selector-<name>=service, and optionally micro.mu/version=<version>.
+	Kubernetes copies the labels of a Service to its EndpointSlices.
+
+	Only ready endpoints are returned. Registering is left to Kubernetes.
+*/
+
+var (
+	// label with the version of the service
+	labelVersionKey = "micro.mu/version"
+
+	// name of the port used if an endpoint slice has more than one
+	endpointPortName = "micro"
+)
+
+// sliceNames returns the names of the micro services an endpoint slice belongs to
+func sliceNames(slice *client.EndpointSlice) []string {
+	if slice == nil || slice.Metadata == nil {
+		return nil
+	}
+
+	var names []string
+	for k, v := range slice.Metadata.Labels {
+		if !strings.HasPrefix(k, svcSelectorPrefix) || v == nil || *v != svcSelectorValue {
+			continue
+		}
+		names = append(names, strings.TrimPrefix(k, svcSelectorPrefix))
+	}
+	return names
+}
+
+func slicePort(slice *client.EndpointSlice) int {
+	var port int
+	for i, p := range slice.Ports {
+		if p.Port == nil {
+			continue
+		}
+		if i == 0 || (p.Name != nil && *p.Name == endpointPortName) {
+			port = *p.Port
+		}
+	}
+	return port
+}
+
+// sliceService builds a service from the ready endpoints of an endpoint slice
+func sliceService(name string, slice *client.EndpointSlice) *registry.Service {
+	service := &registry.Service{
+		Name: name,
+	}
+
+	if slice.Metadata != nil {
+		if v, ok := slice.Metadata.Labels[labelVersionKey]; ok && v != nil {
+			service.Version = *v
+		}
+	}
+
+	port := slicePort(slice)
+
+	for _, ep := range slice.Endpoints {
+		// nil ready condition means ready
+		if ep.Conditions != nil && ep.Conditions.Ready != nil && !*ep.Conditions.Ready {
+			continue
+		}
+
+		if len(ep.Addresses) == 0 {
+			continue
+		}
+
+		node := &registry.Node{
+			Id:       name + ":" + ep.Addresses[0],
+			Address:  ep.Addresses[0],
+			Port:     port,
+			Metadata: map[string]string{},
+		}
+
+		if ep.TargetRef != nil && len(ep.TargetRef.Name) > 0 {
+			node.Id = name + ":" + ep.TargetRef.Name
+		}
+
+		if ep.Zone != nil {
+			node.Metadata["zone"] = *ep.Zone
+		}
+
+		if ep.NodeName != nil {
+			node.Metadata["node"] = *ep.NodeName
+		}
+
+		service.Nodes = append(service.Nodes, node)
+	}
+
+	return service
+}
+
+// getEndpointService builds the versions of a service from its endpoint slices
+func (c *kregistry) getEndpointService(name string) ([]*registry.Service, error) {
+	// svcs mapped by version
+	svcs := make(map[string]*registry.Service)
+
+	err := c.eachClient(func(k *kclient) error {
+		slices, err := k.ListEndpointSlices(map[string]string{
+			svcSelectorPrefix + serviceName(name): svcSelectorValue,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, slice := range slices.Items {
+			svc := sliceService(name, &slice)
+			if len(svc.Nodes) == 0 {
+				continue
+			}
+
+			k.setMetadata(svc, slice.Metadata.Namespace)
+
+			vs, ok := svcs[svc.Version]
+			if !ok {
+				svcs[svc.Version] = svc
+				continue
+			}
+
+			vs.Nodes = append(vs.Nodes, svc.Nodes...)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(svcs) == 0 {
+		return nil, registry.ErrNotFound
+	}
+
+	var list []*registry.Service
+	for _, val := range svcs {
+		list = append(list, val)
+	}
+	return list, nil
+}
+
+// listEndpointServices lists the names of services with endpoint slices
+func (c *kregistry) listEndpointServices() ([]*registry.Service, error) {
+	// svcs mapped by name
+	svcs := make(map[string]bool)
+
+	err := c.eachClient(func(k *kclient) error {
+		slices, err := k.ListEndpointSlices(podSelector)
+		if err != nil {
+			return err
+		}
+
+		for _, slice := range slices.Items {
+			for _, name := range sliceNames(&slice) {
+				svcs[name] = true
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*registry.Service
+	for val := range svcs {
+		list = append(list, &registry.Service{Name: val})
+	}
+	return list, nil
+}
+
+type endpointsWatcher struct {
+	client  *kclient
+	watcher watch.Watch
+	next    chan *registry.Result
+	exit    chan bool
+
+	sync.RWMutex
+	slices map[string]*client.EndpointSlice
+}
+
+// diffSlices compares the old and new state of an endpoint
+// slice and returns the results to send down the wire.
+func diffSlices(old, neu *client.EndpointSlice) []*registry.Result {
+	names := make(map[string]bool)
+	for _, name := range sliceNames(old) {
+		names[name] = true
+	}
+	for _, name := range sliceNames(neu) {
+		names[name] = true
+	}
+
+	var results []*registry.Result
+
+	for name := range names {
+		var os, ns *registry.Service
+		if old != nil {
+			os = sliceService(name, old)
+		}
+		if neu != nil {
+			ns = sliceService(name, neu)
+		}
+
+		if os != nil && ns != nil && reflect.DeepEqual(os, ns) {
+			continue
+		}
+
+		if ns != nil && len(ns.Nodes) > 0 {
+			action := "create"
+			if os != nil && len(os.Nodes) > 0 && os.Version == ns.Version {
+				action = "update"
+			}
+			results = append(results, &registry.Result{Action: action, Service: ns})
+		}
+
+		if os == nil || len(os.Nodes) == 0 {
+			continue
+		}
+
+		// nodes no longer ready or removed from the slice
+		removed := os.Nodes
+		if ns != nil && ns.Version == os.Version {
+			removed = nil
+			for _, o := range os.Nodes {
+				var seen bool
+				for _, n := range ns.Nodes {
+					if o.Id == n.Id {
+						seen = true
+						break
+					}
+				}
+				if !seen {
+					removed = append(removed, o)
+				}
+			}
+		}
+
+		if len(removed) > 0 {
+			results = append(results, &registry.Result{
+				Action: "delete",
+				Service: &registry.Service{
+					Name:    os.Name,
+					Version: os.Version,
+					Nodes:   removed,
+				},
+			})
+		}
+	}
+
+	return results
+}
+
+// handleEvent compares an endpoint slice event against the
+// local cache and sends the results to the watcher.
+func (k *endpointsWatcher) handleEvent(event watch.Event) {
+	var slice client.EndpointSlice
+	if err := json.Unmarshal([]byte(event.Object), &slice); err != nil || slice.Metadata == nil {
+		log.Log("K8s Watcher: Couldnt unmarshal event object from endpoint slice")
+		return
+	}
+
+	k.RLock()
+	cache := k.slices[metaKey(slice.Metadata)]
+	k.RUnlock()
+
+	var results []*registry.Result
+
+	switch event.Type {
+	case watch.Added, watch.Modified:
+		results = diffSlices(cache, &slice)
+
+		k.Lock()
+		k.slices[metaKey(slice.Metadata)] = &slice
+		k.Unlock()
+	case watch.Deleted:
+		if cache == nil {
+			cache = &slice
+		}
+		results = diffSlices(cache, nil)
+
+		k.Lock()
+		delete(k.slices, metaKey(slice.Metadata))
+		k.Unlock()
+	}
+
+	for _, result := range results {
+		k.client.setMetadata(result.Service, slice.Metadata.Namespace)
+
+		select {
+		case k.next <- result:
+		case <-k.exit:
+			return
+		}
+	}
+}
+
+// Next will block until a new result comes in
+func (k *endpointsWatcher) Next() (*registry.Result, error) {
+	select {
+	case r := <-k.next:
+		return r, nil
+	case <-k.exit:
+		return nil, errors.New("watcher stopped")
+	}
+}
+
+// Stop will cancel any requests, and close channels
+func (k *endpointsWatcher) Stop() {
+	select {
+	case <-k.exit:
+		return
+	default:
+		close(k.exit)
+		k.watcher.Stop()
+	}
+}
+
+func newEndpointsWatcher(kc *kclient) (registry.Watcher, error) {
+	// Create watch request
+	watcher, err := kc.WatchEndpointSlices(podSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &endpointsWatcher{
+		client:  kc,
+		watcher: watcher,
+		next:    make(chan *registry.Result),
+		exit:    make(chan bool),
+		slices:  make(map[string]*client.EndpointSlice),
+	}
+
+	// build a cache of endpoint slices, but dont emit changes
+	slices, err := kc.ListEndpointSlices(podSelector)
+	if err != nil {
+		watcher.Stop()
+		return nil, err
+	}
+
+	for i := range slices.Items {
+		slice := slices.Items[i]
+		if slice.Metadata == nil {
+			continue
+		}
+		k.slices[metaKey(slice.Metadata)] = &slice
+	}
+
+	// range over watch request changes, and invoke
+	// the update event
+	go func() {
+		for event := range watcher.ResultChan() {
+			k.handleEvent(event)
+		}
+		k.Stop()
+	}()
+
+	return k, nil
+}
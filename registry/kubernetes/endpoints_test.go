@@ -0,0 +1,203 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-plugins/registry/kubernetes/client"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func setupEndpointsRegistry() registry.Registry {
+	return &kregistry{
+		client:    mockClient,
+		timeout:   time.Second * 1,
+		endpoints: true,
+	}
+}
+
+// builds an endpoint slice for a service, with an endpoint per address
+func setupEndpointSlice(name, service, version string, ready map[string]bool) *client.EndpointSlice {
+	slice := &client.EndpointSlice{
+		Metadata: &client.Meta{
+			Name: name,
+			Labels: map[string]*string{
+				labelTypeKey:                stringPtr(labelTypeValueService),
+				svcSelectorPrefix + service: stringPtr(svcSelectorValue),
+			},
+		},
+		AddressType: "IPv4",
+		Ports: []client.EndpointPort{
+			{Name: stringPtr("http"), Port: intPtr(8080)},
+			{Name: stringPtr(endpointPortName), Port: intPtr(9090)},
+		},
+	}
+
+	if len(version) > 0 {
+		slice.Metadata.Labels[labelVersionKey] = stringPtr(version)
+	}
+
+	for addr, r := range ready {
+		slice.Endpoints = append(slice.Endpoints, client.Endpoint{
+			Addresses:  []string{addr},
+			Conditions: &client.EndpointConditions{Ready: boolPtr(r)},
+			TargetRef:  &client.ObjectReference{Kind: "Pod", Name: "pod-" + addr},
+		})
+	}
+
+	return slice
+}
+
+func TestEndpointsGetService(t *testing.T) {
+	r := setupEndpointsRegistry()
+	defer teardownRegistry()
+
+	mockClient.UpdateEndpointSlice(setupEndpointSlice("foo-abc", "foo.service", "1", map[string]bool{
+		"10.0.1.1": true,
+		"10.0.1.2": false,
+	}))
+	mockClient.UpdateEndpointSlice(setupEndpointSlice("foo-def", "foo.service", "2", map[string]bool{
+		"10.0.2.1": true,
+	}))
+
+	services, err := r.GetService("foo.service")
+	if err != nil {
+		t.Fatalf("did not expect GetService to fail %v", err)
+	}
+
+	if !hasServices(services, []*registry.Service{
+		{
+			Name:    "foo.service",
+			Version: "1",
+			Nodes: []*registry.Node{{
+				Id:       "foo.service:pod-10.0.1.1",
+				Address:  "10.0.1.1",
+				Port:     9090,
+				Metadata: map[string]string{},
+			}},
+		},
+		{
+			Name:    "foo.service",
+			Version: "2",
+			Nodes: []*registry.Node{{
+				Id:       "foo.service:pod-10.0.2.1",
+				Address:  "10.0.2.1",
+				Port:     9090,
+				Metadata: map[string]string{},
+			}},
+		},
+	}) {
+		t.Fatalf("expected services to match, got %+v", services)
+	}
+
+	for _, service := range services {
+		if len(service.Nodes) != 1 {
+			t.Fatalf("expected only ready endpoints, got %d nodes", len(service.Nodes))
+		}
+	}
+
+	if _, err := r.GetService("bar.service"); err != registry.ErrNotFound {
+		t.Fatalf("expected registry.ErrNotFound, got %v", err)
+	}
+}
+
+func TestEndpointsRegister(t *testing.T) {
+	r := setupEndpointsRegistry()
+	defer teardownRegistry()
+
+	svc := &registry.Service{Name: "foo.service"}
+	register(r, "pod-1", svc)
+
+	// pods are not patched
+	p := mockClient.Pods["pod-1"]
+	if _, ok := p.Metadata.Labels[svcSelectorPrefix+"foo.service"]; ok {
+		t.Fatal("expected pod not to be labelled")
+	}
+
+	if _, err := r.GetService("foo.service"); err != registry.ErrNotFound {
+		t.Fatalf("expected registry.ErrNotFound, got %v", err)
+	}
+}
+
+func TestEndpointsListServices(t *testing.T) {
+	r := setupEndpointsRegistry()
+	defer teardownRegistry()
+
+	mockClient.UpdateEndpointSlice(setupEndpointSlice("foo-abc", "foo.service", "", map[string]bool{
+		"10.0.1.1": true,
+	}))
+	mockClient.UpdateEndpointSlice(setupEndpointSlice("bar-abc", "bar.service", "", map[string]bool{
+		"10.0.2.1": true,
+	}))
+
+	services, err := r.ListServices()
+	if err != nil {
+		t.Fatalf("did not expect ListServices to fail %v", err)
+	}
+	if !hasServices(services, []*registry.Service{
+		{Name: "foo.service"},
+		{Name: "bar.service"},
+	}) {
+		t.Fatal("expected services to equal")
+	}
+}
+
+func TestEndpointsWatcher(t *testing.T) {
+	r := setupEndpointsRegistry()
+	defer teardownRegistry()
+
+	w, err := r.Watch()
+	if err != nil {
+		t.Fatalf("did not expect Watch to fail %v", err)
+	}
+	defer w.Stop()
+
+	next := func(action string, ids ...string) {
+		res, err := w.Next()
+		if err != nil {
+			t.Fatalf("did not expect Next to fail %v", err)
+		}
+		if res.Action != action {
+			t.Fatalf("expected action %s, got %s", action, res.Action)
+		}
+		if len(res.Service.Nodes) != len(ids) {
+			t.Fatalf("expected nodes %v, got %+v", ids, res.Service.Nodes)
+		}
+		for i, id := range ids {
+			if res.Service.Nodes[i].Id != id {
+				t.Fatalf("expected nodes %v, got %+v", ids, res.Service.Nodes)
+			}
+		}
+	}
+
+	go mockClient.UpdateEndpointSlice(setupEndpointSlice("foo-abc", "foo.service", "1", map[string]bool{
+		"10.0.1.1": true,
+	}))
+	next("create", "foo.service:pod-10.0.1.1")
+
+	// endpoint no longer ready
+	go mockClient.UpdateEndpointSlice(setupEndpointSlice("foo-abc", "foo.service", "1", map[string]bool{
+		"10.0.1.1": false,
+	}))
+	next("delete", "foo.service:pod-10.0.1.1")
+
+	go mockClient.UpdateEndpointSlice(setupEndpointSlice("foo-abc", "foo.service", "1", map[string]bool{
+		"10.0.1.1": true,
+	}))
+	next("create", "foo.service:pod-10.0.1.1")
+
+	go mockClient.DeleteEndpointSlice("foo-abc")
+	next("delete", "foo.service:pod-10.0.1.1")
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/micro/go-plugins/registry/kubernetes/client"
 
+	"github.com/micro/go-log"
 	"github.com/micro/go-micro/cmd"
 	"github.com/micro/go-micro/registry"
 )
@@ -19,6 +20,12 @@ import (
 type kregistry struct {
 	client  client.Kubernetes
 	timeout time.Duration
+	// resolve services from endpoint slices
+	endpoints bool
+	// clients to discover services with, defaults to client
+	clients []*kclient
+	// err is returned by every method if the registry couldn't be set up
+	err error
 }
 
 var (
@@ -37,6 +44,9 @@ var (
 	// Pod status
 	podRunning = "Running"
 
+	// name of the cluster the registry is running in
+	localCluster = "local"
+
 	// label name regex
 	labelRe = regexp.MustCompilePOSIX("[-A-Za-z0-9_.]")
 )
@@ -68,6 +78,15 @@ func serviceName(name string) string {
 // Register sets a service selector label and an annotation with a
 // serialised version of the service passed in.
 func (c *kregistry) Register(s *registry.Service, opts ...registry.RegisterOption) error {
+	if c.err != nil {
+		return c.err
+	}
+
+	// endpoints are managed by kubernetes
+	if c.endpoints {
+		return nil
+	}
+
 	if len(s.Nodes) == 0 {
 		return errors.New("you must register at least one node")
 	}
@@ -105,6 +124,14 @@ func (c *kregistry) Register(s *registry.Service, opts ...registry.RegisterOptio
 
 // Deregister nils out any things set in Register
 func (c *kregistry) Deregister(s *registry.Service) error {
+	if c.err != nil {
+		return c.err
+	}
+
+	if c.endpoints {
+		return nil
+	}
+
 	if len(s.Nodes) == 0 {
 		return errors.New("you must deregister at least one node")
 	}
@@ -135,46 +162,68 @@ func (c *kregistry) Deregister(s *registry.Service) error {
 // GetService will get all the pods with the given service selector,
 // and build services from the annotations.
 func (c *kregistry) GetService(name string) ([]*registry.Service, error) {
-	pods, err := c.client.ListPods(map[string]string{
-		svcSelectorPrefix + serviceName(name): svcSelectorValue,
-	})
-	if err != nil {
-		return nil, err
+	if c.err != nil {
+		return nil, c.err
 	}
 
-	if len(pods.Items) == 0 {
-		return nil, registry.ErrNotFound
+	if c.endpoints {
+		return c.getEndpointService(name)
 	}
 
 	// svcs mapped by version
 	svcs := make(map[string]*registry.Service)
+	var found bool
 
-	// loop through items
-	for _, pod := range pods.Items {
-		if pod.Status.Phase != podRunning {
-			continue
-		}
-		// get serialised service from annotation
-		svcStr, ok := pod.Metadata.Annotations[annotationServiceKeyPrefix+serviceName(name)]
-		if !ok {
-			continue
+	err := c.eachClient(func(k *kclient) error {
+		pods, err := k.ListPods(map[string]string{
+			svcSelectorPrefix + serviceName(name): svcSelectorValue,
+		})
+		if err != nil {
+			return err
 		}
 
-		// unmarshal service string
-		var svc registry.Service
-		err := json.Unmarshal([]byte(*svcStr), &svc)
-		if err != nil {
-			return nil, fmt.Errorf("could not unmarshal service '%s' from pod annotation", name)
+		if len(pods.Items) > 0 {
+			found = true
 		}
 
-		// merge up pod service & ip with versioned service.
-		vs, ok := svcs[svc.Version]
-		if !ok {
-			svcs[svc.Version] = &svc
-			continue
+		// loop through items
+		for _, pod := range pods.Items {
+			if pod.Status.Phase != podRunning {
+				continue
+			}
+			// get serialised service from annotation
+			svcStr, ok := pod.Metadata.Annotations[annotationServiceKeyPrefix+serviceName(name)]
+			if !ok {
+				continue
+			}
+
+			// unmarshal service string
+			var svc registry.Service
+			err := json.Unmarshal([]byte(*svcStr), &svc)
+			if err != nil {
+				return fmt.Errorf("could not unmarshal service '%s' from pod annotation", name)
+			}
+
+			k.setMetadata(&svc, pod.Metadata.Namespace)
+
+			// merge up pod service & ip with versioned service.
+			vs, ok := svcs[svc.Version]
+			if !ok {
+				svcs[svc.Version] = &svc
+				continue
+			}
+
+			vs.Nodes = append(vs.Nodes, svc.Nodes...)
 		}
 
-		vs.Nodes = append(vs.Nodes, svc.Nodes...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, registry.ErrNotFound
 	}
 
 	var list []*registry.Service
@@ -186,31 +235,46 @@ func (c *kregistry) GetService(name string) ([]*registry.Service, error) {
 
 // ListServices will list all the service names
 func (c *kregistry) ListServices() ([]*registry.Service, error) {
-	pods, err := c.client.ListPods(podSelector)
-	if err != nil {
-		return nil, err
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	if c.endpoints {
+		return c.listEndpointServices()
 	}
 
 	// svcs mapped by name
 	svcs := make(map[string]bool)
 
-	for _, pod := range pods.Items {
-		if pod.Status.Phase != podRunning {
-			continue
+	err := c.eachClient(func(k *kclient) error {
+		pods, err := k.ListPods(podSelector)
+		if err != nil {
+			return err
 		}
-		for k, v := range pod.Metadata.Annotations {
-			if !strings.HasPrefix(k, annotationServiceKeyPrefix) {
-				continue
-			}
 
-			// we have to unmarshal the annotation itself since the
-			// key is encoded to match the regex restriction.
-			var svc registry.Service
-			if err := json.Unmarshal([]byte(*v), &svc); err != nil {
+		for _, pod := range pods.Items {
+			if pod.Status.Phase != podRunning {
 				continue
 			}
-			svcs[svc.Name] = true
+			for k, v := range pod.Metadata.Annotations {
+				if !strings.HasPrefix(k, annotationServiceKeyPrefix) {
+					continue
+				}
+
+				// we have to unmarshal the annotation itself since the
+				// key is encoded to match the regex restriction.
+				var svc registry.Service
+				if err := json.Unmarshal([]byte(*v), &svc); err != nil {
+					continue
+				}
+				svcs[svc.Name] = true
+			}
 		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	var list []*registry.Service
@@ -222,7 +286,37 @@ func (c *kregistry) ListServices() ([]*registry.Service, error) {
 
 // Watch returns a kubernetes watcher
 func (c *kregistry) Watch() (registry.Watcher, error) {
-	return newWatcher(c)
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	clients := c.discovery()
+
+	var watchers []registry.Watcher
+	for _, k := range clients {
+		var w registry.Watcher
+		var err error
+
+		if c.endpoints {
+			w, err = newEndpointsWatcher(k)
+		} else {
+			w, err = newWatcher(k)
+		}
+
+		if err != nil {
+			for _, w := range watchers {
+				w.Stop()
+			}
+			return nil, err
+		}
+
+		watchers = append(watchers, w)
+	}
+
+	if len(watchers) == 1 {
+		return watchers[0], nil
+	}
+	return newMultiWatcher(watchers), nil
 }
 
 func (c *kregistry) String() string {
@@ -237,26 +331,80 @@ func NewRegistry(opts ...registry.Option) registry.Registry {
 		o(&options)
 	}
 
-	// get first host
-	var host string
+	if options.Timeout == 0 {
+		options.Timeout = time.Second * 1
+	}
+
+	var endpoints bool
+	var namespaces, hosts, configs []string
+
+	if options.Context != nil {
+		endpoints, _ = options.Context.Value(endpointSlicesKey{}).(bool)
+		namespaces, _ = options.Context.Value(namespacesKey{}).([]string)
+		hosts, _ = options.Context.Value(clustersKey{}).([]string)
+		configs, _ = options.Context.Value(kubeConfigKey{}).([]string)
+	}
+
+	// only the first address is used unless more clusters are configured
 	if len(options.Addrs) > 0 && len(options.Addrs[0]) > 0 {
-		host = options.Addrs[0]
+		hosts = append([]string{options.Addrs[0]}, hosts...)
 	}
 
-	if options.Timeout == 0 {
-		options.Timeout = time.Second * 1
+	// clients mapped by cluster name, in order
+	var clusters []string
+	clients := make(map[string]client.Kubernetes)
+
+	for _, host := range hosts {
+		if _, ok := clients[host]; ok || len(host) == 0 {
+			continue
+		}
+		clusters = append(clusters, host)
+		clients[host] = client.NewClientByHost(host)
+	}
+
+	var gerr error
+
+	// a config which fails to load is skipped
+	for _, path := range configs {
+		config, err := client.LoadConfig(path)
+		if err != nil {
+			log.Logf("kubernetes: skipping kubeconfig %s: %v", path, err)
+			gerr = err
+			continue
+		}
+		if _, ok := clients[config.Cluster]; ok {
+			continue
+		}
+		clusters = append(clusters, config.Cluster)
+		clients[config.Cluster] = client.NewClientByConfig(config)
+	}
+
+	if len(clusters) == 0 && gerr != nil {
+		return &kregistry{
+			timeout: options.Timeout,
+			err:     gerr,
+		}
 	}
 
 	// if no hosts setup, assume InCluster
-	var c client.Kubernetes
-	if len(host) == 0 {
-		c = client.NewClientInCluster()
-	} else {
-		c = client.NewClientByHost(host)
+	if len(clusters) == 0 {
+		clusters = append(clusters, localCluster)
+		clients[localCluster] = client.NewClientInCluster()
+	}
+
+	clusters, discovery, err := discoveryClients(clusters, clients, namespaces)
+	if len(clusters) == 0 {
+		return &kregistry{
+			timeout: options.Timeout,
+			err:     err,
+		}
 	}
 
 	return &kregistry{
-		client:  c,
-		timeout: options.Timeout,
+		// services are registered in the first cluster
+		client:    clients[clusters[0]],
+		timeout:   options.Timeout,
+		endpoints: endpoints,
+		clients:   discovery,
 	}
 }
@@ -0,0 +1,66 @@
+package kubernetes
+
+import (
+	"github.com/micro/go-micro/registry"
+	"golang.org/x/net/context"
+)
+
+type endpointSlicesKey struct{}
+
+// EndpointSlices resolves services from the endpoint slices of Kubernetes
+// Services instead of pod annotations, so only ready endpoints are returned.
+// Register and Deregister do nothing as endpoints are managed by Kubernetes.
+func EndpointSlices() registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, endpointSlicesKey{}, true)
+	}
+}
+
+type namespacesKey struct{}
+
+type kubeConfigKey struct{}
+
+type clustersKey struct{}
+
+// Namespaces sets the namespaces services are discovered in, by default
+// only the namespace of the registry. An empty namespace is all namespaces.
+func Namespaces(ns ...string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, namespacesKey{}, ns)
+	}
+}
+
+// AllNamespaces discovers services in every namespace
+func AllNamespaces() registry.Option {
+	return Namespaces("")
+}
+
+// Clusters adds a cluster for each API server address. Services in every
+// cluster, including the first address set by registry.Addrs, are merged
+// into one view and are registered with the first cluster.
+func Clusters(hosts ...string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, clustersKey{}, hosts)
+	}
+}
+
+// KubeConfig adds a cluster for the current context of each kubeconfig file.
+// Services in every cluster, including the first address set by registry.Addrs,
+// are merged into one view and are registered with the first cluster.
+func KubeConfig(paths ...string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, kubeConfigKey{}, paths)
+	}
+}
@@ -13,9 +13,10 @@ import (
 )
 
 type k8sWatcher struct {
-	registry *kregistry
-	watcher  watch.Watch
-	next     chan *registry.Result
+	client  *kclient
+	watcher watch.Watch
+	next    chan *registry.Result
+	exit    chan bool
 
 	sync.RWMutex
 	pods map[string]*client.Pod
@@ -23,7 +24,7 @@ type k8sWatcher struct {
 
 // build a cache of pods when the watcher starts.
 func (k *k8sWatcher) updateCache() ([]*registry.Result, error) {
-	podList, err := k.registry.client.ListPods(podSelector)
+	podList, err := k.client.ListPods(podSelector)
 	if err != nil {
 		return nil, err
 	}
@@ -41,7 +42,7 @@ func (k *k8sWatcher) updateCache() ([]*registry.Result, error) {
 		}
 
 		k.Lock()
-		k.pods[pod.Metadata.Name] = &pod
+		k.pods[metaKey(pod.Metadata)] = &pod
 		k.Unlock()
 	}
 
@@ -95,6 +96,7 @@ func (k *k8sWatcher) buildPodResults(pod *client.Pod, cache *client.Pod) []*regi
 				continue
 			}
 
+			k.client.setMetadata(rslt.Service, pod.Metadata.Namespace)
 			results = append(results, rslt)
 		}
 	}
@@ -119,6 +121,7 @@ func (k *k8sWatcher) buildPodResults(pod *client.Pod, cache *client.Pod) []*regi
 				continue
 			}
 
+			k.client.setMetadata(rslt.Service, cache.Metadata.Namespace)
 			results = append(results, rslt)
 		}
 	}
@@ -140,7 +143,7 @@ func (k *k8sWatcher) handleEvent(event watch.Event) {
 		// Pod was modified
 
 		k.RLock()
-		cache := k.pods[pod.Metadata.Name]
+		cache := k.pods[metaKey(pod.Metadata)]
 		k.RUnlock()
 
 		// service could have been added, edited or removed.
@@ -158,11 +161,13 @@ func (k *k8sWatcher) handleEvent(event watch.Event) {
 			if pod.Status.Phase != podRunning {
 				result.Action = "delete"
 			}
-			k.next <- result
+			if !k.send(result) {
+				return
+			}
 		}
 
 		k.Lock()
-		k.pods[pod.Metadata.Name] = &pod
+		k.pods[metaKey(pod.Metadata)] = &pod
 		k.Unlock()
 		return
 
@@ -173,50 +178,63 @@ func (k *k8sWatcher) handleEvent(event watch.Event) {
 
 		for _, result := range results {
 			result.Action = "delete"
-			k.next <- result
+			if !k.send(result) {
+				return
+			}
 		}
 
 		k.Lock()
-		delete(k.pods, pod.Metadata.Name)
+		delete(k.pods, metaKey(pod.Metadata))
 		k.Unlock()
 		return
 	}
 
 }
 
+// send a result to Next, returns false if the watcher is stopped
+func (k *k8sWatcher) send(r *registry.Result) bool {
+	select {
+	case k.next <- r:
+		return true
+	case <-k.exit:
+		return false
+	}
+}
+
 // Next will block until a new result comes in
 func (k *k8sWatcher) Next() (*registry.Result, error) {
-	r, ok := <-k.next
-	if !ok {
+	select {
+	case r := <-k.next:
+		return r, nil
+	case <-k.exit:
 		return nil, errors.New("result chan closed")
 	}
-	return r, nil
 }
 
 // Stop will cancel any requests, and close channels
 func (k *k8sWatcher) Stop() {
-	k.watcher.Stop()
-
 	select {
-	case <-k.next:
+	case <-k.exit:
 		return
 	default:
-		close(k.next)
+		close(k.exit)
+		k.watcher.Stop()
 	}
 }
 
-func newWatcher(kr *kregistry) (registry.Watcher, error) {
+func newWatcher(kc *kclient) (registry.Watcher, error) {
 	// Create watch request
-	watcher, err := kr.client.WatchPods(podSelector)
+	watcher, err := kc.WatchPods(podSelector)
 	if err != nil {
 		return nil, err
 	}
 
 	k := &k8sWatcher{
-		registry: kr,
-		watcher:  watcher,
-		next:     make(chan *registry.Result),
-		pods:     make(map[string]*client.Pod),
+		client:  kc,
+		watcher: watcher,
+		next:    make(chan *registry.Result),
+		exit:    make(chan bool),
+		pods:    make(map[string]*client.Pod),
 	}
 
 	// update cache, but dont emit changes
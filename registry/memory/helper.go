@@ -4,6 +4,20 @@ import (
 	"github.com/micro/go-micro/registry"
 )
 
+func copyService(s *registry.Service) *registry.Service {
+	service := new(registry.Service)
+	*service = *s
+
+	service.Nodes = make([]*registry.Node, len(s.Nodes))
+	for i, n := range s.Nodes {
+		node := new(registry.Node)
+		*node = *n
+		service.Nodes[i] = node
+	}
+
+	return service
+}
+
 func addNodes(old, neu []*registry.Node) []*registry.Node {
 	for _, n := range neu {
 		var seen bool
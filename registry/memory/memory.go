@@ -15,10 +15,24 @@ type memoryRegistry struct {
 	sync.RWMutex
 	services map[string][]*registry.Service
 	watchers map[string]*memoryWatcher
+	expiry   map[nodeKey]time.Time
+	interval time.Duration
+	// the reaper runs while nodes have a ttl
+	reaping bool
+}
+
+// nodeKey identifies a registered node for ttl expiry
+type nodeKey struct {
+	service string
+	version string
+	id      string
 }
 
 var (
 	timeout = time.Millisecond * 10
+
+	// DefaultReapInterval is how often nodes are checked for ttl expiry
+	DefaultReapInterval = time.Second
 )
 
 func init() {
@@ -49,6 +63,67 @@ func (m *memoryRegistry) watch(r *registry.Result) {
 	}
 }
 
+// reap deregisters nodes whose ttl has expired,
+// it returns false once no nodes have a ttl
+func (m *memoryRegistry) reap() bool {
+	var expired []*registry.Service
+	now := time.Now()
+
+	m.Lock()
+	for k, t := range m.expiry {
+		if now.Before(t) {
+			continue
+		}
+
+		delete(m.expiry, k)
+
+		for _, s := range m.services[k.service] {
+			if s.Version != k.version {
+				continue
+			}
+			for _, n := range s.Nodes {
+				if n.Id != k.id {
+					continue
+				}
+				expired = append(expired, &registry.Service{
+					Name:      s.Name,
+					Version:   s.Version,
+					Metadata:  s.Metadata,
+					Endpoints: s.Endpoints,
+					Nodes:     []*registry.Node{n},
+				})
+			}
+		}
+	}
+
+	for _, s := range expired {
+		m.services[s.Name] = delServices(m.services[s.Name], []*registry.Service{s})
+	}
+
+	reaping := len(m.expiry) > 0
+	if !reaping {
+		m.reaping = false
+	}
+	m.Unlock()
+
+	for _, s := range expired {
+		m.watch(&registry.Result{Action: "delete", Service: s})
+	}
+
+	return reaping
+}
+
+func (m *memoryRegistry) run() {
+	t := time.NewTicker(m.interval)
+	defer t.Stop()
+
+	for _ = range t.C {
+		if !m.reap() {
+			return
+		}
+	}
+}
+
 func (m *memoryRegistry) GetService(service string) ([]*registry.Service, error) {
 	m.RLock()
 	s, ok := m.services[service]
@@ -73,7 +148,30 @@ func (m *memoryRegistry) ListServices() ([]*registry.Service, error) {
 func (m *memoryRegistry) Register(s *registry.Service, opts ...registry.RegisterOption) error {
 	go m.watch(&registry.Result{Action: "update", Service: s})
 
+	var options registry.RegisterOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	// store a copy so the caller's service isn't changed as nodes come and go
+	s = copyService(s)
+
 	m.Lock()
+	// set or refresh the expiry of the nodes
+	for _, n := range s.Nodes {
+		k := nodeKey{s.Name, s.Version, n.Id}
+		if options.TTL > 0 {
+			m.expiry[k] = time.Now().Add(options.TTL)
+		} else {
+			delete(m.expiry, k)
+		}
+	}
+
+	if len(m.expiry) > 0 && !m.reaping {
+		m.reaping = true
+		go m.run()
+	}
+
 	services := addServices(m.services[s.Name], []*registry.Service{s})
 	m.services[s.Name] = services
 	m.Unlock()
@@ -84,6 +182,10 @@ func (m *memoryRegistry) Deregister(s *registry.Service) error {
 	go m.watch(&registry.Result{Action: "delete", Service: s})
 
 	m.Lock()
+	for _, n := range s.Nodes {
+		delete(m.expiry, nodeKey{s.Name, s.Version, n.Id})
+	}
+
 	services := delServices(m.services[s.Name], []*registry.Service{s})
 	m.services[s.Name] = services
 	m.Unlock()
@@ -121,8 +223,17 @@ func NewRegistry(opts ...registry.Option) registry.Registry {
 		services = make(map[string][]*registry.Service)
 	}
 
-	return &memoryRegistry{
+	interval := DefaultReapInterval
+	if i, ok := options.Context.Value(reapIntervalKey{}).(time.Duration); ok && i > 0 {
+		interval = i
+	}
+
+	m := &memoryRegistry{
 		services: services,
 		watchers: make(map[string]*memoryWatcher),
+		expiry:   make(map[nodeKey]time.Time),
+		interval: interval,
 	}
+
+	return m
 }
@@ -2,6 +2,7 @@ package memory
 
 import (
 	"testing"
+	"time"
 
 	"github.com/micro/go-micro/registry"
 )
@@ -130,3 +131,99 @@ func TestMemoryRegistry(t *testing.T) {
 		}
 	}
 }
+
+func TestMemoryRegistryTTL(t *testing.T) {
+	m := NewRegistry(ReapInterval(time.Millisecond * 10))
+
+	service := &registry.Service{
+		Name:    "foo",
+		Version: "1.0.0",
+		Nodes: []*registry.Node{
+			{
+				Id:      "foo-1.0.0-123",
+				Address: "localhost",
+				Port:    9999,
+			},
+		},
+	}
+
+	w, err := m.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	if err := m.Register(service, registry.RegisterTTL(time.Millisecond*50)); err != nil {
+		t.Fatal(err)
+	}
+
+	// re-registering refreshes the ttl
+	for i := 0; i < 5; i++ {
+		time.Sleep(time.Millisecond * 20)
+		if err := m.Register(service, registry.RegisterTTL(time.Millisecond*50)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := m.GetService("foo"); err != nil {
+			t.Fatalf("Expected service to be refreshed, got %v", err)
+		}
+	}
+
+	// wait for the node to expire
+	for {
+		r, err := w.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Action != "delete" {
+			continue
+		}
+		if len(r.Service.Nodes) != 1 || r.Service.Nodes[0].Id != "foo-1.0.0-123" {
+			t.Fatalf("Expected expired node foo-1.0.0-123, got %+v", r.Service.Nodes)
+		}
+		break
+	}
+
+	if _, err := m.GetService("foo"); err != registry.ErrNotFound {
+		t.Fatalf("Expected %v, got %v", registry.ErrNotFound, err)
+	}
+
+	// the registered service is left as it was
+	if len(service.Nodes) != 1 {
+		t.Fatalf("Expected the registered service to keep its node, got %+v", service.Nodes)
+	}
+
+	// nodes without a ttl never expire
+	if err := m.Register(&registry.Service{
+		Name:    "foo",
+		Version: "1.0.0",
+		Nodes: []*registry.Node{
+			{
+				Id:      "foo-1.0.0-456",
+				Address: "localhost",
+				Port:    9999,
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(time.Millisecond * 100)
+
+	services, err := m.GetService("foo")
+	if err != nil {
+		t.Fatalf("Expected service without ttl to remain, got %v", err)
+	}
+	if len(services) != 1 || len(services[0].Nodes) != 1 {
+		t.Fatalf("Expected 1 node without ttl, got %+v", services)
+	}
+
+	// the reaper stops once no nodes have a ttl
+	mr := m.(*memoryRegistry)
+	mr.RLock()
+	reaping := mr.reaping
+	mr.RUnlock()
+
+	if reaping {
+		t.Fatal("Expected the reaper to stop")
+	}
+}
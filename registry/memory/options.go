@@ -1,11 +1,14 @@
 package memory
 
 import (
+	"time"
+
 	"github.com/micro/go-micro/registry"
 	"golang.org/x/net/context"
 )
 
 type servicesKey struct{}
+type reapIntervalKey struct{}
 
 func getServices(ctx context.Context) map[string][]*registry.Service {
 	s, ok := ctx.Value(servicesKey{}).(map[string][]*registry.Service)
@@ -24,3 +27,13 @@ func Services(s map[string][]*registry.Service) registry.Option {
 		o.Context = context.WithValue(o.Context, servicesKey{}, s)
 	}
 }
+
+// ReapInterval sets how often nodes registered with a ttl are checked for expiry
+func ReapInterval(d time.Duration) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, reapIntervalKey{}, d)
+	}
+}
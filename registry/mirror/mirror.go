@@ -0,0 +1,484 @@
+// Package mirror replicates the services of one registry into another
+package mirror
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/registry"
+)
+
+/*
+	The mirror copies the services of a source registry into a destination
+	registry e.g from consul into etcd for clients which can only read etcd.
+
+	On start every service is copied across. After that the source is watched
+	and create, update and delete events are replayed into the destination.
+	Nodes are registered with a TTL and re-registered every interval, which
+	also resyncs the destination with the source in case events were missed.
+*/
+
+// Mirror copies services from a source to a destination registry
+type Mirror interface {
+	// Start syncs the destination and starts watching the source
+	Start() error
+	// Stop watching the source. Mirrored nodes are left to expire.
+	Stop()
+}
+
+type Options struct {
+	// Services to mirror, by default all services
+	Services []string
+	// Metadata nodes must have to be mirrored
+	Metadata map[string]string
+	// TTL nodes are registered with in the destination, zero is no TTL
+	TTL time.Duration
+	// Interval at which nodes are re-registered and the source resynced
+	Interval time.Duration
+}
+
+type Option func(o *Options)
+
+type mirror struct {
+	src  registry.Registry
+	dst  registry.Registry
+	opts Options
+
+	sync.Mutex
+	// services registered in the destination
+	services map[string][]*registry.Service
+	running  bool
+	exit     chan bool
+}
+
+var (
+	// DefaultTTL is the TTL nodes are registered with in the destination
+	DefaultTTL = time.Minute
+	// DefaultInterval is how often nodes are re-registered
+	DefaultInterval = time.Second * 20
+)
+
+// Services sets the names of the services to mirror
+func Services(names ...string) Option {
+	return func(o *Options) {
+		o.Services = names
+	}
+}
+
+// Metadata only mirrors nodes with all of the metadata
+func Metadata(md map[string]string) Option {
+	return func(o *Options) {
+		o.Metadata = md
+	}
+}
+
+// TTL sets the TTL nodes are registered with in the destination,
+// zero is no TTL and negative values keep the default
+func TTL(t time.Duration) Option {
+	return func(o *Options) {
+		o.TTL = t
+	}
+}
+
+// Interval sets how often nodes are re-registered and the source resynced.
+// It should be less than the TTL. Values <= 0 keep the default.
+func Interval(t time.Duration) Option {
+	return func(o *Options) {
+		o.Interval = t
+	}
+}
+
+func backoff(attempts int) time.Duration {
+	if attempts == 0 {
+		return time.Duration(0)
+	}
+	d := time.Duration(math.Pow(10, float64(attempts))) * time.Millisecond
+	if d > time.Minute {
+		d = time.Minute
+	}
+	return d
+}
+
+// filter returns a copy of the service with only the nodes to be mirrored
+func (m *mirror) filter(s *registry.Service) *registry.Service {
+	if s == nil || !m.mirrored(s.Name) {
+		return nil
+	}
+
+	service := copyService(s)
+	service.Nodes = nil
+
+	for _, node := range s.Nodes {
+		if !hasMetadata(node, m.opts.Metadata) {
+			continue
+		}
+		n := *node
+		service.Nodes = append(service.Nodes, &n)
+	}
+
+	if len(service.Nodes) == 0 {
+		return nil
+	}
+
+	return service
+}
+
+func (m *mirror) mirrored(name string) bool {
+	if len(m.opts.Services) == 0 {
+		return true
+	}
+	for _, s := range m.opts.Services {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// register copies the service into the destination, the lock must be held.
+// Mirrored nodes of the service which no longer match the filter are removed.
+func (m *mirror) register(s *registry.Service) error {
+	if s == nil {
+		return nil
+	}
+
+	service := m.filter(s)
+
+	var unmatched []*registry.Node
+	for _, node := range s.Nodes {
+		if service == nil || !hasNode([]*registry.Service{service}, s.Version, node.Id) {
+			unmatched = append(unmatched, node)
+		}
+	}
+
+	if len(unmatched) > 0 {
+		del := copyService(s)
+		del.Nodes = unmatched
+		if err := m.deregister(del); err != nil {
+			return err
+		}
+	}
+
+	if service == nil {
+		return nil
+	}
+
+	var opts []registry.RegisterOption
+	if m.opts.TTL > 0 {
+		opts = append(opts, registry.RegisterTTL(m.opts.TTL))
+	}
+
+	if err := m.dst.Register(copyService(service), opts...); err != nil {
+		return err
+	}
+
+	m.services[service.Name] = addServices(m.services[service.Name], []*registry.Service{service})
+	return nil
+}
+
+// deregister removes the nodes of the service which were
+// mirrored from the destination, the lock must be held
+func (m *mirror) deregister(s *registry.Service) error {
+	if s == nil {
+		return nil
+	}
+
+	var nodes []*registry.Node
+	for _, service := range m.services[s.Name] {
+		if service.Version != s.Version {
+			continue
+		}
+		for _, node := range service.Nodes {
+			for _, n := range s.Nodes {
+				if n.Id == node.Id {
+					nodes = append(nodes, node)
+				}
+			}
+		}
+	}
+
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	service := copyService(s)
+	service.Nodes = nodes
+
+	if err := m.dst.Deregister(copyService(service)); err != nil {
+		return err
+	}
+
+	services := delServices(m.services[s.Name], []*registry.Service{service})
+	if len(services) == 0 {
+		delete(m.services, s.Name)
+	} else {
+		m.services[s.Name] = services
+	}
+
+	return nil
+}
+
+// sync registers every service of the source in the destination
+// and deregisters the mirrored nodes no longer in the source
+func (m *mirror) sync() error {
+	// hold the lock so events are not applied while syncing
+	m.Lock()
+	defer m.Unlock()
+
+	names := m.opts.Services
+
+	if len(names) == 0 {
+		services, err := m.src.ListServices()
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]bool)
+		for _, s := range services {
+			if !seen[s.Name] {
+				seen[s.Name] = true
+				names = append(names, s.Name)
+			}
+		}
+	}
+
+	current := make(map[string][]*registry.Service)
+
+	for _, name := range names {
+		services, err := m.src.GetService(name)
+		if err == registry.ErrNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+		for _, service := range services {
+			if f := m.filter(service); f != nil {
+				current[name] = append(current[name], f)
+			}
+		}
+	}
+
+	for _, services := range current {
+		for _, s := range services {
+			if err := m.register(s); err != nil {
+				log.Logf("mirror: error registering %s: %v", s.Name, err)
+			}
+		}
+	}
+
+	// remove nodes which have gone from the source
+	var stale []*registry.Service
+	for name, services := range m.services {
+		for _, s := range services {
+			var nodes []*registry.Node
+			for _, node := range s.Nodes {
+				if !hasNode(current[name], s.Version, node.Id) {
+					nodes = append(nodes, node)
+				}
+			}
+			if len(nodes) > 0 {
+				service := copyService(s)
+				service.Nodes = nodes
+				stale = append(stale, service)
+			}
+		}
+	}
+
+	for _, s := range stale {
+		if err := m.deregister(s); err != nil {
+			log.Logf("mirror: error deregistering %s: %v", s.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (m *mirror) update(res *registry.Result) {
+	if res == nil || res.Service == nil {
+		return
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	var err error
+
+	switch res.Action {
+	case "create", "update":
+		err = m.register(res.Service)
+	case "delete":
+		err = m.deregister(res.Service)
+	}
+
+	if err != nil {
+		log.Logf("mirror: error replaying %s of %s: %v", res.Action, res.Service.Name, err)
+	}
+}
+
+// run watches the source and replays events into the destination
+func (m *mirror) run(w registry.Watcher, exit chan bool) {
+	var a, b int
+
+	for {
+		if w == nil {
+			// wait before retrying
+			select {
+			case <-exit:
+				return
+			case <-time.After(backoff(a + b)):
+			}
+
+			var err error
+			w, err = m.src.Watch()
+			if err != nil {
+				a++
+				log.Logf("mirror: error creating watcher: %v", err)
+				continue
+			}
+			a = 0
+
+			// events may have been missed while not watching
+			if err := m.sync(); err != nil {
+				log.Logf("mirror: error syncing: %v", err)
+			}
+		}
+
+		err := m.watch(w, exit)
+		w = nil
+
+		select {
+		case <-exit:
+			return
+		default:
+		}
+
+		if err != nil {
+			b++
+			log.Logf("mirror: error watching registry: %v", err)
+			continue
+		}
+		b = 0
+	}
+}
+
+func (m *mirror) watch(w registry.Watcher, exit chan bool) error {
+	// stop the watcher on exit or once done
+	done := make(chan bool)
+	defer close(done)
+
+	go func() {
+		select {
+		case <-exit:
+		case <-done:
+		}
+		w.Stop()
+	}()
+
+	for {
+		res, err := w.Next()
+		if err != nil {
+			select {
+			case <-exit:
+				return nil
+			default:
+				return err
+			}
+		}
+		m.update(res)
+	}
+}
+
+// refresh re-registers the nodes so they don't expire and
+// resyncs the destination with the source every interval
+func (m *mirror) refresh(exit chan bool) {
+	t := time.NewTicker(m.opts.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-exit:
+			return
+		case <-t.C:
+			if err := m.sync(); err != nil {
+				log.Logf("mirror: error syncing: %v", err)
+			}
+		}
+	}
+}
+
+func (m *mirror) Start() error {
+	m.Lock()
+	if m.running {
+		m.Unlock()
+		return nil
+	}
+	m.Unlock()
+
+	// watch before syncing so no events are missed
+	w, err := m.src.Watch()
+	if err != nil {
+		return err
+	}
+
+	if err := m.sync(); err != nil {
+		w.Stop()
+		return err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.running {
+		w.Stop()
+		return nil
+	}
+
+	m.running = true
+	m.exit = make(chan bool)
+
+	go m.run(w, m.exit)
+	go m.refresh(m.exit)
+
+	return nil
+}
+
+func (m *mirror) Stop() {
+	m.Lock()
+	defer m.Unlock()
+
+	if !m.running {
+		return
+	}
+
+	m.running = false
+	close(m.exit)
+}
+
+// New returns a mirror which copies services from src to dst
+func New(src, dst registry.Registry, opts ...Option) Mirror {
+	options := Options{
+		TTL:      DefaultTTL,
+		Interval: DefaultInterval,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if options.Interval <= 0 {
+		options.Interval = DefaultInterval
+	}
+
+	// zero is no ttl
+	if options.TTL < 0 {
+		options.TTL = DefaultTTL
+	}
+
+	return &mirror{
+		src:      src,
+		dst:      dst,
+		opts:     options,
+		services: make(map[string][]*registry.Service),
+	}
+}
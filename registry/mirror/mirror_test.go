@@ -0,0 +1,197 @@
+package mirror
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-plugins/registry/memory"
+)
+
+func testService(name, version, id, env string) *registry.Service {
+	return &registry.Service{
+		Name:    name,
+		Version: version,
+		Nodes: []*registry.Node{
+			{
+				Id:       id,
+				Address:  "localhost",
+				Port:     8080,
+				Metadata: map[string]string{"env": env},
+			},
+		},
+	}
+}
+
+// nodes returns the ids of the nodes of a service
+func nodes(r registry.Registry, name string) map[string]bool {
+	ids := make(map[string]bool)
+	services, _ := r.GetService(name)
+	for _, s := range services {
+		for _, n := range s.Nodes {
+			ids[n.Id] = true
+		}
+	}
+	return ids
+}
+
+func TestMirror(t *testing.T) {
+	src := memory.NewRegistry()
+	dst := memory.NewRegistry()
+
+	src.Register(testService("foo", "1.0.0", "foo-1", "prod"))
+	src.Register(testService("foo", "1.0.1", "foo-2", "dev"))
+	src.Register(testService("bar", "1.0.0", "bar-1", "prod"))
+
+	m := New(src, dst, Metadata(map[string]string{"env": "prod"}), Interval(time.Hour))
+	if err := m.Start(); err != nil {
+		t.Fatalf("Unexpected error starting mirror: %v", err)
+	}
+	defer m.Stop()
+
+	if ids := nodes(dst, "foo"); len(ids) != 1 || !ids["foo-1"] {
+		t.Fatalf("Expected only foo-1 to be mirrored, got %v", ids)
+	}
+
+	if ids := nodes(dst, "bar"); len(ids) != 1 || !ids["bar-1"] {
+		t.Fatalf("Expected bar-1 to be mirrored, got %v", ids)
+	}
+
+	// events are replayed
+	baz := testService("baz", "1.0.0", "baz-1", "prod")
+	src.Register(baz)
+
+	time.Sleep(time.Millisecond * 100)
+
+	if ids := nodes(dst, "baz"); !ids["baz-1"] {
+		t.Fatalf("Expected baz-1 to be mirrored, got %v", ids)
+	}
+
+	src.Deregister(testService("baz", "1.0.0", "baz-1", "prod"))
+
+	time.Sleep(time.Millisecond * 100)
+
+	if ids := nodes(dst, "baz"); len(ids) != 0 {
+		t.Fatalf("Expected baz-1 to be deregistered, got %v", ids)
+	}
+
+	// nodes which stop matching the filter are removed
+	m.(*mirror).update(&registry.Result{
+		Action:  "update",
+		Service: testService("foo", "1.0.0", "foo-1", "dev"),
+	})
+
+	if ids := nodes(dst, "foo"); len(ids) != 0 {
+		t.Fatalf("Expected foo-1 to be removed, got %v", ids)
+	}
+}
+
+func TestMirrorServices(t *testing.T) {
+	src := memory.NewRegistry()
+	dst := memory.NewRegistry()
+
+	src.Register(testService("foo", "1.0.0", "foo-1", "prod"))
+	src.Register(testService("bar", "1.0.0", "bar-1", "prod"))
+
+	m := New(src, dst, Services("foo"), Interval(time.Hour))
+	if err := m.Start(); err != nil {
+		t.Fatalf("Unexpected error starting mirror: %v", err)
+	}
+	defer m.Stop()
+
+	if ids := nodes(dst, "foo"); !ids["foo-1"] {
+		t.Fatalf("Expected foo-1 to be mirrored, got %v", ids)
+	}
+
+	if ids := nodes(dst, "bar"); len(ids) != 0 {
+		t.Fatalf("Expected bar not to be mirrored, got %v", ids)
+	}
+}
+
+// ttlRegistry records the TTL of registrations
+type ttlRegistry struct {
+	registry.Registry
+
+	sync.Mutex
+	ttls []time.Duration
+}
+
+func (r *ttlRegistry) Register(s *registry.Service, opts ...registry.RegisterOption) error {
+	var options registry.RegisterOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	r.Lock()
+	r.ttls = append(r.ttls, options.TTL)
+	r.Unlock()
+
+	return r.Registry.Register(s, opts...)
+}
+
+func (r *ttlRegistry) registrations() []time.Duration {
+	r.Lock()
+	defer r.Unlock()
+	return r.ttls
+}
+
+func TestMirrorTTL(t *testing.T) {
+	src := memory.NewRegistry()
+	dst := &ttlRegistry{Registry: memory.NewRegistry()}
+
+	src.Register(testService("foo", "1.0.0", "foo-1", "prod"))
+
+	m := New(src, dst, TTL(time.Millisecond*100), Interval(time.Millisecond*20))
+	if err := m.Start(); err != nil {
+		t.Fatalf("Unexpected error starting mirror: %v", err)
+	}
+
+	// nodes are re-registered before they expire
+	time.Sleep(time.Millisecond * 110)
+	m.Stop()
+
+	ttls := dst.registrations()
+	if len(ttls) < 3 {
+		t.Fatalf("Expected foo to be re-registered every interval, got %d registrations", len(ttls))
+	}
+
+	for _, ttl := range ttls {
+		if ttl != time.Millisecond*100 {
+			t.Fatalf("Expected registration with ttl 100ms, got %v", ttl)
+		}
+	}
+
+	// and are left to expire once the mirror stops
+	time.Sleep(time.Millisecond * 50)
+
+	if n := len(dst.registrations()); n != len(ttls) {
+		t.Fatalf("Expected no registrations after stop, got %d", n-len(ttls))
+	}
+}
+
+func TestOptions(t *testing.T) {
+	src := memory.NewRegistry()
+	dst := memory.NewRegistry()
+
+	for _, d := range []time.Duration{0, -time.Second} {
+		m := New(src, dst, Interval(d)).(*mirror)
+		if m.opts.Interval != DefaultInterval {
+			t.Fatalf("Expected interval %v for %v, got %v", DefaultInterval, d, m.opts.Interval)
+		}
+
+		// the mirror starts and stops without panicking
+		if err := m.Start(); err != nil {
+			t.Fatal(err)
+		}
+		m.Stop()
+	}
+
+	if m := New(src, dst, TTL(-time.Second)).(*mirror); m.opts.TTL != DefaultTTL {
+		t.Fatalf("Expected ttl %v, got %v", DefaultTTL, m.opts.TTL)
+	}
+
+	if m := New(src, dst, TTL(0)).(*mirror); m.opts.TTL != 0 {
+		t.Fatalf("Expected no ttl, got %v", m.opts.TTL)
+	}
+}
@@ -0,0 +1,115 @@
+package mirror
+
+import (
+	"github.com/micro/go-micro/registry"
+)
+
+func addNodes(old, neu []*registry.Node) []*registry.Node {
+	for _, n := range neu {
+		var seen bool
+		for i, o := range old {
+			if o.Id == n.Id {
+				seen = true
+				old[i] = n
+				break
+			}
+		}
+		if !seen {
+			old = append(old, n)
+		}
+	}
+	return old
+}
+
+func addServices(old, neu []*registry.Service) []*registry.Service {
+	for _, s := range neu {
+		var seen bool
+		for i, o := range old {
+			if o.Version == s.Version {
+				s.Nodes = addNodes(o.Nodes, s.Nodes)
+				seen = true
+				old[i] = s
+				break
+			}
+		}
+		if !seen {
+			old = append(old, s)
+		}
+	}
+	return old
+}
+
+func delNodes(old, del []*registry.Node) []*registry.Node {
+	var nodes []*registry.Node
+	for _, o := range old {
+		var rem bool
+		for _, n := range del {
+			if o.Id == n.Id {
+				rem = true
+				break
+			}
+		}
+		if !rem {
+			nodes = append(nodes, o)
+		}
+	}
+	return nodes
+}
+
+func delServices(old, del []*registry.Service) []*registry.Service {
+	var services []*registry.Service
+	for i, o := range old {
+		var rem bool
+		for _, s := range del {
+			if o.Version == s.Version {
+				old[i].Nodes = delNodes(o.Nodes, s.Nodes)
+				if len(old[i].Nodes) == 0 {
+					rem = true
+				}
+			}
+		}
+		if !rem {
+			services = append(services, o)
+		}
+	}
+	return services
+}
+
+func copyService(s *registry.Service) *registry.Service {
+	service := new(registry.Service)
+	*service = *s
+
+	service.Nodes = make([]*registry.Node, len(s.Nodes))
+	for i, node := range s.Nodes {
+		n := new(registry.Node)
+		*n = *node
+		service.Nodes[i] = n
+	}
+
+	return service
+}
+
+// hasMetadata returns true if the node has all of the metadata
+func hasMetadata(node *registry.Node, md map[string]string) bool {
+	for k, v := range md {
+		if node.Metadata[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// hasNode returns true if a version of the services has the node
+func hasNode(services []*registry.Service, version, id string) bool {
+	for _, s := range services {
+		if s.Version != version {
+			continue
+		}
+		for _, n := range s.Nodes {
+			if n.Id == id {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+// Package multi provides a registry which aggregates multiple registries
+package multi
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/micro/go-micro/registry"
+	"golang.org/x/net/context"
+)
+
+/*
+	The multi registry fans Register and Deregister out to every backend
+	and merges the results of GetService and ListServices. Nodes are merged
+	by id and where two backends return the same node, the one with the
+	highest priority wins. Watch multiplexes the watchers of every backend.
+*/
+
+type backend struct {
+	registry registry.Registry
+	priority int
+	// the order the backend was configured in, which breaks ties
+	order int
+}
+
+type multiRegistry struct {
+	opts     registry.Options
+	backends []*backend
+}
+
+type byPriority []*backend
+
+func (b byPriority) Len() int           { return len(b) }
+func (b byPriority) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byPriority) Less(i, j int) bool { return b[i].priority > b[j].priority }
+
+// each calls fn concurrently for every backend and combines the errors
+func (m *multiRegistry) each(fn func(int, *backend) error) error {
+	var mtx sync.Mutex
+	var wg sync.WaitGroup
+	var errs []string
+
+	for i, b := range m.backends {
+		wg.Add(1)
+		go func(i int, b *backend) {
+			defer wg.Done()
+			if err := fn(i, b); err != nil {
+				mtx.Lock()
+				errs = append(errs, fmt.Sprintf("%s: %v", b.registry.String(), err))
+				mtx.Unlock()
+			}
+		}(i, b)
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Register registers the service with every backend. Each backend is
+// given its own copy of the service as some modify it.
+func (m *multiRegistry) Register(s *registry.Service, opts ...registry.RegisterOption) error {
+	return m.each(func(i int, b *backend) error {
+		return b.registry.Register(cloneService(s), opts...)
+	})
+}
+
+func (m *multiRegistry) Deregister(s *registry.Service) error {
+	return m.each(func(i int, b *backend) error {
+		return b.registry.Deregister(cloneService(s))
+	})
+}
+
+func (m *multiRegistry) GetService(name string) ([]*registry.Service, error) {
+	results := make([][]*registry.Service, len(m.backends))
+
+	err := m.each(func(i int, b *backend) error {
+		services, err := b.registry.GetService(name)
+		if err != nil && err != registry.ErrNotFound {
+			return err
+		}
+		results[i] = services
+		return nil
+	})
+
+	// backends are sorted by priority so the first node seen wins
+	var services []*registry.Service
+	for _, result := range results {
+		services = merge(services, result)
+	}
+
+	if len(services) > 0 {
+		return services, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return nil, registry.ErrNotFound
+}
+
+func (m *multiRegistry) ListServices() ([]*registry.Service, error) {
+	var mtx sync.Mutex
+	var ok bool
+	names := make(map[string]bool)
+
+	err := m.each(func(i int, b *backend) error {
+		services, err := b.registry.ListServices()
+		if err != nil {
+			return err
+		}
+		mtx.Lock()
+		ok = true
+		for _, s := range services {
+			names[s.Name] = true
+		}
+		mtx.Unlock()
+		return nil
+	})
+
+	// fail only if no backend responded
+	if err != nil && !ok {
+		return nil, err
+	}
+
+	var services []*registry.Service
+	for name := range names {
+		services = append(services, &registry.Service{Name: name})
+	}
+	return services, nil
+}
+
+func (m *multiRegistry) Watch() (registry.Watcher, error) {
+	return newWatcher(m.backends)
+}
+
+func (m *multiRegistry) String() string {
+	return "multi"
+}
+
+// NewRegistry returns a registry which aggregates the registries
+// specified with the Backend option
+func NewRegistry(opts ...registry.Option) registry.Registry {
+	options := registry.Options{
+		Context: context.Background(),
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	var backends []*backend
+	if b, ok := options.Context.Value(backendsKey{}).([]*backend); ok {
+		backends = append(backends, b...)
+	}
+
+	for i, b := range backends {
+		b.order = i
+	}
+
+	// highest priority first
+	sort.Stable(byPriority(backends))
+
+	return &multiRegistry{
+		opts:     options,
+		backends: backends,
+	}
+}
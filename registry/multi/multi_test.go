@@ -0,0 +1,287 @@
+package multi
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-plugins/registry/memory"
+)
+
+func testService(id, address string) *registry.Service {
+	return &registry.Service{
+		Name:    "foo",
+		Version: "1.0.0",
+		Nodes: []*registry.Node{
+			{
+				Id:      id,
+				Address: address,
+				Port:    8080,
+			},
+		},
+	}
+}
+
+func TestGetService(t *testing.T) {
+	low := memory.NewRegistry()
+	high := memory.NewRegistry()
+
+	low.Register(testService("foo-1", "10.0.0.1"))
+	low.Register(testService("foo-2", "10.0.0.2"))
+	high.Register(testService("foo-1", "10.1.0.1"))
+	high.Register(testService("foo-3", "10.1.0.3"))
+
+	r := NewRegistry(
+		Backend(low, 0),
+		Backend(high, 10),
+	)
+
+	services, err := r.GetService("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(services) != 1 {
+		t.Fatalf("Expected 1 service, got %d", len(services))
+	}
+
+	addrs := make(map[string]string)
+	for _, n := range services[0].Nodes {
+		addrs[n.Id] = n.Address
+	}
+
+	expected := map[string]string{
+		"foo-1": "10.1.0.1",
+		"foo-2": "10.0.0.2",
+		"foo-3": "10.1.0.3",
+	}
+
+	for id, addr := range expected {
+		if addrs[id] != addr {
+			t.Fatalf("Expected node %s with address %s, got %s", id, addr, addrs[id])
+		}
+	}
+
+	if _, err := r.GetService("bar"); err != registry.ErrNotFound {
+		t.Fatalf("Expected %v, got %v", registry.ErrNotFound, err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	a := memory.NewRegistry()
+	b := memory.NewRegistry()
+
+	r := NewRegistry(
+		Backend(a, 0),
+		Backend(b, 0),
+	)
+
+	service := testService("foo-1", "10.0.0.1")
+	if err := r.Register(service); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, backend := range []registry.Registry{a, b} {
+		if _, err := backend.GetService("foo"); err != nil {
+			t.Fatalf("Expected service registered in every backend, got %v", err)
+		}
+	}
+
+	services, err := r.ListServices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 1 || services[0].Name != "foo" {
+		t.Fatalf("Expected only foo to be listed, got %+v", services)
+	}
+
+	if err := r.Deregister(service); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, backend := range []registry.Registry{a, b} {
+		if _, err := backend.GetService("foo"); err != registry.ErrNotFound {
+			t.Fatalf("Expected service deregistered from every backend, got %v", err)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	low := &backend{priority: 0}
+	high := &backend{priority: 10}
+
+	w := &multiWatcher{
+		nodes: make(map[string]map[*backend]*registry.Node),
+	}
+
+	data := []struct {
+		backend *backend
+		action  string
+		address string
+		// expected results
+		results []string
+	}{
+		{low, "create", "10.0.0.1", []string{"create 10.0.0.1"}},
+		{high, "create", "10.1.0.1", []string{"create 10.1.0.1"}},
+		// lower priority changes are hidden by the higher priority node
+		{low, "update", "10.0.0.2", nil},
+		// removing the higher priority node falls back to the lower one
+		{high, "delete", "10.1.0.1", []string{"update 10.0.0.2"}},
+		{low, "delete", "10.0.0.2", []string{"delete 10.0.0.2"}},
+	}
+
+	for i, d := range data {
+		results := w.resolve(d.backend, &registry.Result{
+			Action:  d.action,
+			Service: testService("foo-1", d.address),
+		})
+
+		var got []string
+		for _, r := range results {
+			for _, n := range r.Service.Nodes {
+				got = append(got, r.Action+" "+n.Address)
+			}
+		}
+
+		if len(got) != len(d.results) {
+			t.Fatalf("%d: Expected results %v, got %v", i, d.results, got)
+		}
+		for j := range got {
+			if got[j] != d.results[j] {
+				t.Fatalf("%d: Expected results %v, got %v", i, d.results, got)
+			}
+		}
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	a := memory.NewRegistry()
+	b := memory.NewRegistry()
+
+	r := NewRegistry(
+		Backend(a, 0),
+		Backend(b, 0),
+	)
+
+	w, err := r.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	a.Register(testService("foo-1", "10.0.0.1"))
+	b.Register(testService("foo-2", "10.0.0.2"))
+
+	seen := make(map[string]bool)
+	for len(seen) < 2 {
+		res, err := w.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, n := range res.Service.Nodes {
+			seen[n.Id] = true
+		}
+	}
+}
+
+func TestResolveOrder(t *testing.T) {
+	first := &backend{order: 0}
+	second := &backend{order: 1}
+
+	w := &multiWatcher{
+		nodes: make(map[string]map[*backend]*registry.Node),
+	}
+
+	w.resolve(first, &registry.Result{Action: "create", Service: testService("foo-1", "10.0.0.1")})
+
+	// the first configured backend wins between equal priorities
+	for i := 0; i < 10; i++ {
+		if results := w.resolve(second, &registry.Result{Action: "update", Service: testService("foo-1", "10.0.0.2")}); len(results) != 0 {
+			t.Fatalf("Expected the second backend to be hidden, got %+v", results)
+		}
+	}
+}
+
+func TestResolveUnknownDelete(t *testing.T) {
+	w := &multiWatcher{
+		nodes: make(map[string]map[*backend]*registry.Node),
+	}
+
+	// a node registered before watching is deleted
+	results := w.resolve(&backend{}, &registry.Result{Action: "delete", Service: testService("foo-1", "10.0.0.1")})
+
+	if len(results) != 1 || results[0].Action != "delete" || len(results[0].Service.Nodes) != 1 {
+		t.Fatalf("Expected the delete to be passed on, got %+v", results)
+	}
+
+	if len(w.nodes) != 0 {
+		t.Fatalf("Expected no nodes to be held, got %d", len(w.nodes))
+	}
+}
+
+// failingRegistry's first watcher fails
+type failingRegistry struct {
+	registry.Registry
+	failed bool
+}
+
+type failingWatcher struct{}
+
+func (f *failingWatcher) Next() (*registry.Result, error) {
+	return nil, errors.New("watcher failed")
+}
+
+func (f *failingWatcher) Stop() {}
+
+func (f *failingRegistry) Watch() (registry.Watcher, error) {
+	if !f.failed {
+		f.failed = true
+		return &failingWatcher{}, nil
+	}
+	return f.Registry.Watch()
+}
+
+func TestWatcherRestart(t *testing.T) {
+	retry := retryInterval
+	retryInterval = time.Millisecond * 10
+	defer func() {
+		retryInterval = retry
+	}()
+
+	a := memory.NewRegistry()
+	b := &failingRegistry{Registry: memory.NewRegistry()}
+
+	r := NewRegistry(
+		Backend(a, 0),
+		Backend(b, 0),
+	)
+
+	w, err := r.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	a.Register(testService("foo-1", "10.0.0.1"))
+
+	res, err := w.Next()
+	if err != nil {
+		t.Fatalf("Expected the failed backend to be skipped, got %v", err)
+	}
+	if res.Service.Nodes[0].Id != "foo-1" {
+		t.Fatalf("Expected foo-1, got %+v", res.Service.Nodes)
+	}
+
+	// the failed backend is watched again
+	time.Sleep(time.Millisecond * 50)
+	b.Register(testService("foo-2", "10.0.0.2"))
+
+	res, err = w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Service.Nodes[0].Id != "foo-2" {
+		t.Fatalf("Expected foo-2, got %+v", res.Service.Nodes)
+	}
+}
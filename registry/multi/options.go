@@ -0,0 +1,21 @@
+package multi
+
+import (
+	"github.com/micro/go-micro/registry"
+	"golang.org/x/net/context"
+)
+
+type backendsKey struct{}
+
+// Backend adds a registry to aggregate. Where backends return the same
+// node the one with the highest priority wins.
+func Backend(r registry.Registry, priority int) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		b, _ := o.Context.Value(backendsKey{}).([]*backend)
+		b = append(b, &backend{registry: r, priority: priority})
+		o.Context = context.WithValue(o.Context, backendsKey{}, b)
+	}
+}
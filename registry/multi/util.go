@@ -0,0 +1,52 @@
+package multi
+
+import (
+	"github.com/micro/go-micro/registry"
+)
+
+func copyService(s *registry.Service) *registry.Service {
+	service := new(registry.Service)
+	*service = *s
+	service.Nodes = nil
+	return service
+}
+
+func cloneService(s *registry.Service) *registry.Service {
+	service := copyService(s)
+	service.Nodes = make([]*registry.Node, len(s.Nodes))
+	copy(service.Nodes, s.Nodes)
+	return service
+}
+
+// merge adds the services of neu to old without modifying neu.
+// Nodes already in old take precedence over those in neu.
+func merge(old, neu []*registry.Service) []*registry.Service {
+	for _, s := range neu {
+		var service *registry.Service
+		for _, o := range old {
+			if o.Version == s.Version {
+				service = o
+				break
+			}
+		}
+
+		if service == nil {
+			service = copyService(s)
+			old = append(old, service)
+		}
+
+		for _, n := range s.Nodes {
+			var seen bool
+			for _, o := range service.Nodes {
+				if o.Id == n.Id {
+					seen = true
+					break
+				}
+			}
+			if !seen {
+				service.Nodes = append(service.Nodes, n)
+			}
+		}
+	}
+	return old
+}
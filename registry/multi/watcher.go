@@ -0,0 +1,222 @@
+package multi
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+)
+
+type result struct {
+	backend *backend
+	res     *registry.Result
+}
+
+type multiWatcher struct {
+	next  chan *result
+	exit  chan bool
+	retry time.Duration
+
+	sync.Mutex
+	// the current watcher of each backend
+	watchers map[*backend]registry.Watcher
+	pending  []*registry.Result
+	// the nodes held by each backend keyed by service, version and node id
+	nodes map[string]map[*backend]*registry.Node
+}
+
+// retryInterval is how long to wait before watching a failed backend again
+var retryInterval = time.Second
+
+func newWatcher(backends []*backend) (registry.Watcher, error) {
+	w := &multiWatcher{
+		next:     make(chan *result),
+		exit:     make(chan bool),
+		retry:    retryInterval,
+		watchers: make(map[*backend]registry.Watcher),
+		nodes:    make(map[string]map[*backend]*registry.Node),
+	}
+
+	for _, b := range backends {
+		bw, err := b.registry.Watch()
+		if err != nil {
+			w.Stop()
+			return nil, err
+		}
+		w.watchers[b] = bw
+		go w.watch(b, bw)
+	}
+
+	return w, nil
+}
+
+// setWatcher replaces the watcher of the backend, it returns
+// false if the multi watcher was stopped
+func (w *multiWatcher) setWatcher(b *backend, bw registry.Watcher) bool {
+	w.Lock()
+	defer w.Unlock()
+
+	select {
+	case <-w.exit:
+		if bw != nil {
+			bw.Stop()
+		}
+		return false
+	default:
+	}
+
+	w.watchers[b] = bw
+	return true
+}
+
+// watch passes on the results of the backend. A failed watcher is
+// restarted so one backend doesn't stop the results of the others.
+func (w *multiWatcher) watch(b *backend, bw registry.Watcher) {
+	for {
+		if bw != nil {
+			res, err := bw.Next()
+			if err == nil {
+				select {
+				case w.next <- &result{backend: b, res: res}:
+				case <-w.exit:
+					return
+				}
+				continue
+			}
+
+			bw.Stop()
+		}
+
+		select {
+		case <-time.After(w.retry):
+		case <-w.exit:
+			return
+		}
+
+		bw, _ = b.registry.Watch()
+		if !w.setWatcher(b, bw) {
+			return
+		}
+	}
+}
+
+// best returns the highest priority backend holding the node,
+// of equal priorities the first configured
+func best(holders map[*backend]*registry.Node) *backend {
+	var b *backend
+	for h := range holders {
+		if b == nil || h.priority > b.priority || (h.priority == b.priority && h.order < b.order) {
+			b = h
+		}
+	}
+	return b
+}
+
+// resolve filters a result from a backend so that only changes to nodes
+// held by the highest priority backend are passed on. Deleting a node
+// from the highest priority backend falls back to the next backend
+// holding it, which is passed on as an update.
+func (w *multiWatcher) resolve(b *backend, r *registry.Result) []*registry.Result {
+	if r == nil || r.Service == nil {
+		return []*registry.Result{r}
+	}
+
+	var nodes, updates []*registry.Node
+
+	for _, n := range r.Service.Nodes {
+		key := r.Service.Name + "/" + r.Service.Version + "/" + n.Id
+
+		holders, ok := w.nodes[key]
+
+		if r.Action == "delete" {
+			// registered before watching so nothing is known of it
+			if !ok {
+				nodes = append(nodes, n)
+				continue
+			}
+
+			prev := best(holders)
+			delete(holders, b)
+
+			switch {
+			case prev != b:
+				// not the node being served
+			case len(holders) == 0:
+				delete(w.nodes, key)
+				nodes = append(nodes, n)
+			default:
+				updates = append(updates, holders[best(holders)])
+			}
+			continue
+		}
+
+		if !ok {
+			holders = make(map[*backend]*registry.Node)
+			w.nodes[key] = holders
+		}
+
+		holders[b] = n
+
+		if best(holders) == b {
+			nodes = append(nodes, n)
+		}
+	}
+
+	var results []*registry.Result
+
+	if len(nodes) > 0 || len(r.Service.Nodes) == 0 {
+		service := new(registry.Service)
+		*service = *r.Service
+		service.Nodes = nodes
+		results = append(results, &registry.Result{Action: r.Action, Service: service})
+	}
+
+	if len(updates) > 0 {
+		service := new(registry.Service)
+		*service = *r.Service
+		service.Nodes = updates
+		results = append(results, &registry.Result{Action: "update", Service: service})
+	}
+
+	return results
+}
+
+func (w *multiWatcher) Next() (*registry.Result, error) {
+	for {
+		w.Lock()
+		if len(w.pending) > 0 {
+			r := w.pending[0]
+			w.pending = w.pending[1:]
+			w.Unlock()
+			return r, nil
+		}
+		w.Unlock()
+
+		select {
+		case r := <-w.next:
+			w.Lock()
+			w.pending = append(w.pending, w.resolve(r.backend, r.res)...)
+			w.Unlock()
+		case <-w.exit:
+			return nil, errors.New("watcher stopped")
+		}
+	}
+}
+
+func (w *multiWatcher) Stop() {
+	w.Lock()
+	defer w.Unlock()
+
+	select {
+	case <-w.exit:
+		return
+	default:
+		close(w.exit)
+		for _, bw := range w.watchers {
+			if bw != nil {
+				bw.Stop()
+			}
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package nats
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/nats-io/nats"
+)
+
+/*
+	In cache mode every registry keeps a local copy of the services announced
+	on the watch topic. Registries publish create and delete events as services
+	are registered and deregistered, and a heartbeat for their services every
+	heartbeat interval. Nodes which are not heard from within the ttl expire.
+
+	GetService and ListServices are served from the cache. A broadcast list
+	query is only made once, after subscribing, to learn the services which
+	were registered before the registry started.
+*/
+
+var (
+	// DefaultHeartbeatInterval is how often services are announced in cache mode
+	DefaultHeartbeatInterval = time.Second * 30
+	// DefaultCacheTTL is how long a node is cached after it was last announced
+	DefaultCacheTTL = time.Second * 90
+)
+
+type cache struct {
+	ttl time.Duration
+
+	sync.RWMutex
+	services map[string][]*registry.Service
+	// expiry of each node, keyed by service name, version and node id
+	expiry map[string]time.Time
+
+	// guards the subscription and initial sync
+	smtx sync.Mutex
+	sub  *nats.Subscription
+}
+
+func newCache(ttl time.Duration) *cache {
+	return &cache{
+		ttl:      ttl,
+		services: make(map[string][]*registry.Service),
+		expiry:   make(map[string]time.Time),
+	}
+}
+
+func nodeKey(s *registry.Service, n *registry.Node) string {
+	return s.Name + "/" + s.Version + "/" + n.Id
+}
+
+func copyService(s *registry.Service) *registry.Service {
+	service := new(registry.Service)
+	*service = *s
+
+	service.Nodes = make([]*registry.Node, len(s.Nodes))
+	for i, node := range s.Nodes {
+		n := new(registry.Node)
+		*n = *node
+		service.Nodes[i] = n
+	}
+
+	return service
+}
+
+// update adds the nodes of the service and resets their expiry
+func (c *cache) update(s *registry.Service) {
+	if s == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	expires := time.Now().Add(c.ttl)
+	for _, node := range s.Nodes {
+		c.expiry[nodeKey(s, node)] = expires
+	}
+
+	c.services[s.Name] = addServices(c.services[s.Name], []*registry.Service{copyService(s)})
+}
+
+// remove deletes the nodes of the service
+func (c *cache) remove(s *registry.Service) {
+	if s == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	for _, node := range s.Nodes {
+		delete(c.expiry, nodeKey(s, node))
+	}
+
+	c.del(s.Name, delServices(c.services[s.Name], []*registry.Service{s}))
+}
+
+// del stores the services or deletes the name if there are none left
+func (c *cache) del(name string, services []*registry.Service) {
+	if len(services) == 0 {
+		delete(c.services, name)
+		return
+	}
+	c.services[name] = services
+}
+
+// expire removes the nodes of a service which have not been announced within the ttl
+func (c *cache) expire(name string) {
+	now := time.Now()
+
+	var expired []*registry.Service
+	for _, service := range c.services[name] {
+		var nodes []*registry.Node
+		for _, node := range service.Nodes {
+			if exp, ok := c.expiry[nodeKey(service, node)]; ok && exp.After(now) {
+				continue
+			}
+			delete(c.expiry, nodeKey(service, node))
+			nodes = append(nodes, node)
+		}
+		if len(nodes) > 0 {
+			expired = append(expired, &registry.Service{Version: service.Version, Nodes: nodes})
+		}
+	}
+
+	if len(expired) > 0 {
+		c.del(name, delServices(c.services[name], expired))
+	}
+}
+
+func (c *cache) get(name string) []*registry.Service {
+	c.Lock()
+	defer c.Unlock()
+
+	c.expire(name)
+
+	var services []*registry.Service
+	for _, service := range c.services[name] {
+		services = append(services, copyService(service))
+	}
+	return services
+}
+
+func (c *cache) list() []*registry.Service {
+	c.Lock()
+	defer c.Unlock()
+
+	var services []*registry.Service
+	for name := range c.services {
+		c.expire(name)
+		if _, ok := c.services[name]; ok {
+			services = append(services, &registry.Service{Name: name})
+		}
+	}
+	return services
+}
+
+func (c *cache) handle(r *registry.Result) {
+	switch r.Action {
+	case "create", "update", "heartbeat":
+		c.update(r.Service)
+	case "delete":
+		c.remove(r.Service)
+	}
+}
+
+// syncCache subscribes to the watch topic and queries the services
+// already registered. It is a no-op once the cache is synced.
+func (n *natsRegistry) syncCache() error {
+	n.cache.smtx.Lock()
+	defer n.cache.smtx.Unlock()
+
+	if n.cache.sub != nil {
+		return nil
+	}
+
+	conn, err := n.getConn()
+	if err != nil {
+		return err
+	}
+
+	// subscribe before querying so no events are missed
+	sub, err := conn.Subscribe(n.watchTopic, func(m *nats.Msg) {
+		var result *registry.Result
+		if err := json.Unmarshal(m.Data, &result); err != nil || result == nil {
+			return
+		}
+		n.cache.handle(result)
+	})
+	if err != nil {
+		return err
+	}
+
+	services, err := n.query("", 0)
+	if err != nil {
+		sub.Unsubscribe()
+		return err
+	}
+
+	for _, service := range services {
+		n.cache.update(service)
+	}
+
+	n.cache.sub = sub
+	return nil
+}
+
+// heartbeat announces the services registered with
+// this registry so they do not expire from caches
+func (n *natsRegistry) heartbeat() {
+	t := time.NewTicker(n.heartbeatInterval)
+	defer t.Stop()
+
+	for range t.C {
+		n.RLock()
+		var services []*registry.Service
+		for _, s := range n.services {
+			services = append(services, s...)
+		}
+		n.RUnlock()
+
+		if len(services) == 0 {
+			continue
+		}
+
+		conn, err := n.getConn()
+		if err != nil {
+			continue
+		}
+
+		for _, service := range services {
+			n.RLock()
+			b, err := json.Marshal(&registry.Result{Action: "heartbeat", Service: service})
+			n.RUnlock()
+			if err != nil {
+				continue
+			}
+			conn.Publish(n.watchTopic, b)
+		}
+	}
+}
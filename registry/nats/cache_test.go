@@ -0,0 +1,74 @@
+package nats_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-plugins/registry/nats"
+)
+
+func TestCache(t *testing.T) {
+	addr := os.Getenv("NATS_URL")
+
+	one := nats.NewRegistry(registry.Addrs(addr), nats.Cache())
+	two := nats.NewRegistry(registry.Addrs(addr), nats.Cache(), nats.CacheTTL(time.Millisecond*500))
+
+	// services registered before the cache is synced are queried
+	services, err := two.GetService("one")
+	assertNoError(t, err)
+	assertEqual(t, 1, len(services))
+	assertEqual(t, 1, len(services[0].Nodes))
+
+	service := registry.Service{
+		Name:    "cache",
+		Version: "default",
+		Nodes:   []*registry.Node{{Id: "cache-1"}},
+	}
+
+	assertNoError(t, one.Register(&service))
+
+	// wait for the event
+	time.Sleep(time.Millisecond * 100)
+
+	services, err = two.GetService("cache")
+	assertNoError(t, err)
+	if len(services) != 1 || len(services[0].Nodes) != 1 {
+		t.Fatalf("expected 1 service with 1 node, got %+v", services)
+	}
+	assertEqual(t, "cache-1", services[0].Nodes[0].Id)
+
+	list, err := two.ListServices()
+	assertNoError(t, err)
+
+	var listed bool
+	for _, s := range list {
+		if s.Name == "cache" {
+			listed = true
+		}
+	}
+	assertEqual(t, true, listed)
+
+	assertNoError(t, one.Deregister(&service))
+	time.Sleep(time.Millisecond * 100)
+
+	services, err = two.GetService("cache")
+	assertNoError(t, err)
+	assertEqual(t, 0, len(services))
+
+	// without heartbeats the node expires
+	assertNoError(t, one.Register(&service))
+	defer one.Deregister(&service)
+	time.Sleep(time.Millisecond * 100)
+
+	services, err = two.GetService("cache")
+	assertNoError(t, err)
+	assertEqual(t, 1, len(services))
+
+	time.Sleep(time.Millisecond * 500)
+
+	services, err = two.GetService("cache")
+	assertNoError(t, err)
+	assertEqual(t, 0, len(services))
+}
@@ -1,4 +1,5 @@
-// Package nats provides a NATS registry using broadcast queries
+// Package nats provides a NATS registry using broadcast queries,
+// or a local cache of the services announced on the watch topic
 package nats
 
 import (
@@ -25,6 +26,11 @@ type natsRegistry struct {
 	conn      *nats.Conn
 	services  map[string][]*registry.Service
 	listeners map[string]chan bool
+
+	// cache is only set in cache mode
+	cache             *cache
+	heartbeatInterval time.Duration
+	heartbeatOnce     sync.Once
 }
 
 var (
@@ -269,6 +275,13 @@ func (n *natsRegistry) Register(s *registry.Service, opts ...registry.RegisterOp
 		return err
 	}
 
+	if n.cache != nil {
+		n.cache.update(s)
+		n.heartbeatOnce.Do(func() {
+			go n.heartbeat()
+		})
+	}
+
 	b, err := json.Marshal(&registry.Result{Action: "create", Service: s})
 	if err != nil {
 		return err
@@ -287,6 +300,10 @@ func (n *natsRegistry) Deregister(s *registry.Service) error {
 		return err
 	}
 
+	if n.cache != nil {
+		n.cache.remove(s)
+	}
+
 	b, err := json.Marshal(&registry.Result{Action: "delete", Service: s})
 	if err != nil {
 		return err
@@ -295,6 +312,13 @@ func (n *natsRegistry) Deregister(s *registry.Service) error {
 }
 
 func (n *natsRegistry) GetService(s string) ([]*registry.Service, error) {
+	if n.cache != nil {
+		if err := n.syncCache(); err != nil {
+			return nil, err
+		}
+		return n.cache.get(s), nil
+	}
+
 	services, err := n.query(s, getQuorum(n.opts))
 	if err != nil {
 		return nil, err
@@ -303,6 +327,13 @@ func (n *natsRegistry) GetService(s string) ([]*registry.Service, error) {
 }
 
 func (n *natsRegistry) ListServices() ([]*registry.Service, error) {
+	if n.cache != nil {
+		if err := n.syncCache(); err != nil {
+			return nil, err
+		}
+		return n.cache.list(), nil
+	}
+
 	s, err := n.query("", 0)
 	if err != nil {
 		return nil, err
@@ -384,13 +415,29 @@ func NewRegistry(opts ...registry.Option) registry.Registry {
 	// stored in natsRegistry.addrs and options.Addrs are identical)
 	options.Addrs = setAddrs(options.Addrs)
 
+	heartbeatInterval := DefaultHeartbeatInterval
+	if hi, ok := options.Context.Value(heartbeatIntervalKey{}).(time.Duration); ok && hi > 0 {
+		heartbeatInterval = hi
+	}
+
+	var c *cache
+	if on, ok := options.Context.Value(cacheKey{}).(bool); ok && on {
+		ttl := DefaultCacheTTL
+		if t, ok := options.Context.Value(cacheTTLKey{}).(time.Duration); ok && t > 0 {
+			ttl = t
+		}
+		c = newCache(ttl)
+	}
+
 	return &natsRegistry{
-		addrs:      options.Addrs,
-		opts:       options,
-		nopts:      natsOptions,
-		queryTopic: queryTopic,
-		watchTopic: watchTopic,
-		services:   make(map[string][]*registry.Service),
-		listeners:  make(map[string]chan bool),
+		addrs:             options.Addrs,
+		opts:              options,
+		nopts:             natsOptions,
+		queryTopic:        queryTopic,
+		watchTopic:        watchTopic,
+		services:          make(map[string][]*registry.Service),
+		listeners:         make(map[string]chan bool),
+		cache:             c,
+		heartbeatInterval: heartbeatInterval,
 	}
 }
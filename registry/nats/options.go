@@ -1,6 +1,8 @@
 package nats
 
 import (
+	"time"
+
 	"github.com/micro/go-micro/registry"
 	"github.com/nats-io/nats"
 	"golang.org/x/net/context"
@@ -10,6 +12,9 @@ type contextQuorumKey struct{}
 type optionsKey struct{}
 type watchTopicKey struct{}
 type queryTopicKey struct{}
+type cacheKey struct{}
+type cacheTTLKey struct{}
+type heartbeatIntervalKey struct{}
 
 var (
 	DefaultQuorum = 0
@@ -69,3 +74,38 @@ func WatchTopic(s string) registry.Option {
 		o.Context = context.WithValue(o.Context, watchTopicKey{}, s)
 	}
 }
+
+// Cache enables cache mode. Registries announce their services on the watch
+// topic and keep a local cache of the services announced by others, which is
+// used to answer GetService and ListServices instead of broadcast queries.
+// Every registry sharing the watch topic should have cache mode enabled.
+func Cache() registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, cacheKey{}, true)
+	}
+}
+
+// CacheTTL sets how long a node is cached after it was last announced.
+// It should be a few times the heartbeat interval. Values <= 0 keep the default.
+func CacheTTL(d time.Duration) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, cacheTTLKey{}, d)
+	}
+}
+
+// HeartbeatInterval sets how often registered services are announced in cache mode.
+// Values <= 0 keep the default.
+func HeartbeatInterval(d time.Duration) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, heartbeatIntervalKey{}, d)
+	}
+}
@@ -161,3 +161,23 @@ func TestWatchQueryTopic(t *testing.T) {
 		t.Fatal("timeout - no data received on watch topic")
 	}
 }
+
+func TestCacheOptions(t *testing.T) {
+	for _, d := range []time.Duration{0, -time.Second} {
+		reg := NewRegistry(Cache(), CacheTTL(d), HeartbeatInterval(d)).(*natsRegistry)
+
+		if reg.heartbeatInterval != DefaultHeartbeatInterval {
+			t.Errorf("Expected heartbeat interval %v for %v, got %v", DefaultHeartbeatInterval, d, reg.heartbeatInterval)
+		}
+
+		if reg.cache.ttl != DefaultCacheTTL {
+			t.Errorf("Expected cache ttl %v for %v, got %v", DefaultCacheTTL, d, reg.cache.ttl)
+		}
+	}
+
+	reg := NewRegistry(Cache(), CacheTTL(time.Minute), HeartbeatInterval(time.Second)).(*natsRegistry)
+
+	if reg.heartbeatInterval != time.Second || reg.cache.ttl != time.Minute {
+		t.Errorf("Expected heartbeat interval 1s and cache ttl 1m, got %v and %v", reg.heartbeatInterval, reg.cache.ttl)
+	}
+}
@@ -21,9 +21,14 @@ func (n *natsWatcher) Next() (*registry.Result, error) {
 		} else if err != nil {
 			return nil, err
 		}
+		result = nil
 		if err := json.Unmarshal(m.Data, &result); err != nil {
 			return nil, err
 		}
+		// heartbeats only keep caches alive, they are not changes
+		if result != nil && result.Action == "heartbeat" {
+			continue
+		}
 		break
 	}
 	return result, nil
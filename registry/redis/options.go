@@ -0,0 +1,18 @@
+package redis
+
+import (
+	"github.com/micro/go-micro/registry"
+	"golang.org/x/net/context"
+)
+
+type prefixKey struct{}
+
+// Prefix sets the prefix of the keys and channel of the registry
+func Prefix(p string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, prefixKey{}, p)
+	}
+}
@@ -0,0 +1,174 @@
+package redis
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+)
+
+func testRegistry(t *testing.T) registry.Registry {
+	url := os.Getenv("REDIS_URL")
+	if url == "" {
+		t.Skip("REDIS_URL not defined")
+	}
+
+	// a prefix per test so tests don't see each other's services
+	return NewRegistry(registry.Addrs(url), Prefix("micro-test-"+t.Name()))
+}
+
+func testService(id string) *registry.Service {
+	return &registry.Service{
+		Name:    "foo",
+		Version: "1.0.0",
+		Nodes: []*registry.Node{
+			{Id: id, Address: "localhost", Port: 8080},
+		},
+	}
+}
+
+func next(t *testing.T, w registry.Watcher) *registry.Result {
+	results := make(chan *registry.Result, 1)
+
+	go func() {
+		r, err := w.Next()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		results <- r
+	}()
+
+	select {
+	case r := <-results:
+		return r
+	case <-time.After(time.Second * 5):
+		t.Fatal("Expected watch result")
+	}
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	r := testRegistry(t)
+
+	if err := r.Register(testService("foo-1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(testService("foo-2")); err != nil {
+		t.Fatal(err)
+	}
+	defer r.Deregister(testService("foo-2"))
+
+	services, err := r.GetService("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(services) != 1 || len(services[0].Nodes) != 2 {
+		t.Fatalf("Expected foo with 2 nodes, got %+v", services)
+	}
+
+	list, err := r.ListServices()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 1 || list[0].Name != "foo" {
+		t.Fatalf("Expected foo to be listed, got %+v", list)
+	}
+
+	if err := r.Deregister(testService("foo-1")); err != nil {
+		t.Fatal(err)
+	}
+
+	services, err = r.GetService("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(services[0].Nodes) != 1 || services[0].Nodes[0].Id != "foo-2" {
+		t.Fatalf("Expected only foo-2, got %+v", services[0].Nodes)
+	}
+
+	if _, err := r.GetService("bar"); err != registry.ErrNotFound {
+		t.Fatalf("Expected not found, got %v", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	r := testRegistry(t)
+
+	w, err := r.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	if err := r.Register(testService("foo-1")); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := next(t, w); res.Action != "create" || res.Service.Nodes[0].Id != "foo-1" {
+		t.Fatalf("Expected create of foo-1, got %s %+v", res.Action, res.Service)
+	}
+
+	// unchanged registrations are skipped
+	if err := r.Register(testService("foo-1")); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := testService("foo-1")
+	changed.Nodes[0].Port = 8081
+
+	if err := r.Register(changed); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := next(t, w); res.Action != "update" || res.Service.Nodes[0].Port != 8081 {
+		t.Fatalf("Expected update of foo-1, got %s %+v", res.Action, res.Service)
+	}
+
+	if err := r.Deregister(changed); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := next(t, w); res.Action != "delete" || res.Service.Nodes[0].Id != "foo-1" {
+		t.Fatalf("Expected delete of foo-1, got %s %+v", res.Action, res.Service)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	r := testRegistry(t)
+
+	if err := r.Register(testService("foo-1"), registry.RegisterTTL(time.Millisecond*200)); err != nil {
+		t.Fatal(err)
+	}
+
+	// re-registering resets the expiry
+	for i := 0; i < 3; i++ {
+		time.Sleep(time.Millisecond * 100)
+		if err := r.Register(testService("foo-1"), registry.RegisterTTL(time.Millisecond*200)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := r.GetService("foo"); err != nil {
+		t.Fatalf("Expected foo-1 to be registered, got %v", err)
+	}
+
+	time.Sleep(time.Millisecond * 300)
+
+	if _, err := r.GetService("foo"); err != registry.ErrNotFound {
+		t.Fatalf("Expected foo-1 to expire, got %v", err)
+	}
+
+	list, err := r.ListServices()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 0 {
+		t.Fatalf("Expected no services once expired, got %+v", list)
+	}
+}
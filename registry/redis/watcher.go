@@ -0,0 +1,141 @@
+package redis
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/micro/go-micro/registry"
+)
+
+// expired is the pattern of the channels of expired keyspace events
+const expired = "__keyevent@*__:expired"
+
+type redisWatcher struct {
+	registry *redisRegistry
+	conn     redis.PubSubConn
+	// the last known service of each node key, for expired events
+	nodes map[string]*registry.Service
+	stop  chan bool
+}
+
+func newRedisWatcher(r *redisRegistry) (registry.Watcher, error) {
+	c, err := r.dial(redis.DialWriteTimeout(r.options.Timeout))
+	if err != nil {
+		return nil, err
+	}
+
+	conn := redis.PubSubConn{Conn: c}
+
+	if err := conn.Subscribe(r.channel()); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if err := conn.PSubscribe(expired); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// wait until subscribed so no events are missed while reading the nodes
+	for i := 0; i < 2; i++ {
+		switch v := conn.ReceiveWithTimeout(r.options.Timeout).(type) {
+		case redis.Subscription:
+		case error:
+			conn.Close()
+			return nil, v
+		}
+	}
+
+	nodes, err := r.snapshot()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &redisWatcher{
+		registry: r,
+		conn:     conn,
+		nodes:    nodes,
+		stop:     make(chan bool),
+	}, nil
+}
+
+// snapshot returns every node mapped by key
+func (r *redisRegistry) snapshot() (map[string]*registry.Service, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	names, err := redis.Strings(conn.Do("SMEMBERS", r.servicesKey()))
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[string]*registry.Service)
+
+	for _, name := range names {
+		services, err := r.nodes(conn, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range services {
+			nodes[r.nodeKey(s.Name, s.Nodes[0].Id)] = s
+		}
+	}
+
+	return nodes, nil
+}
+
+func (rw *redisWatcher) Next() (*registry.Result, error) {
+	for {
+		switch v := rw.conn.Receive().(type) {
+		case redis.Message:
+			var res *registry.Result
+			if err := json.Unmarshal(v.Data, &res); err != nil {
+				continue
+			}
+			if res == nil || res.Service == nil || len(res.Service.Nodes) == 0 {
+				continue
+			}
+
+			key := rw.registry.nodeKey(res.Service.Name, res.Service.Nodes[0].Id)
+			if res.Action == "delete" {
+				delete(rw.nodes, key)
+			} else {
+				rw.nodes[key] = res.Service
+			}
+
+			return res, nil
+		case redis.PMessage:
+			// the node expired, get the service from the last event
+			key := string(v.Data)
+			service, ok := rw.nodes[key]
+			if !ok {
+				continue
+			}
+			delete(rw.nodes, key)
+
+			return &registry.Result{
+				Action:  "delete",
+				Service: service,
+			}, nil
+		case error:
+			select {
+			case <-rw.stop:
+				return nil, errors.New("watcher stopped")
+			default:
+				return nil, v
+			}
+		}
+	}
+}
+
+func (rw *redisWatcher) Stop() {
+	select {
+	case <-rw.stop:
+		return
+	default:
+		close(rw.stop)
+		rw.conn.Close()
+	}
+}
@@ -0,0 +1,57 @@
+package zookeeper
+
+import (
+	"github.com/micro/go-micro/registry"
+	"github.com/samuel/go-zookeeper/zk"
+	"golang.org/x/net/context"
+)
+
+type prefixKey struct{}
+
+type aclKey struct{}
+
+type authKey struct{}
+
+type authInfo struct {
+	scheme string
+	auth   []byte
+}
+
+// Prefix sets the znode services are registered under, by default /micro-registry.
+// It's cleaned so "/foo/" and "foo" are both /foo.
+func Prefix(p string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, prefixKey{}, p)
+	}
+}
+
+// ACL sets the ACL of the znodes created by the registry, by default
+// znodes are world readable and writable.
+func ACL(acl ...zk.ACL) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, aclKey{}, acl)
+	}
+}
+
+// Auth adds credentials for an authentication scheme to the session
+func Auth(scheme string, auth []byte) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		a, _ := o.Context.Value(authKey{}).([]authInfo)
+		a = append(a, authInfo{scheme, auth})
+		o.Context = context.WithValue(o.Context, authKey{}, a)
+	}
+}
+
+// DigestAuth authenticates the session with a digest user and password
+func DigestAuth(user, password string) registry.Option {
+	return Auth("digest", []byte(user+":"+password))
+}
@@ -1,9 +1,12 @@
 package zookeeper
 
 import (
+	"crypto/tls"
 	"encoding/json"
+	"net"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/micro/go-micro/registry"
 	"github.com/samuel/go-zookeeper/zk"
@@ -19,17 +22,17 @@ func decode(ds []byte) (*registry.Service, error) {
 	return s, err
 }
 
-func nodePath(s, id string) string {
+func nodePath(prefix, s, id string) string {
 	service := strings.Replace(s, "/", "-", -1)
 	node := strings.Replace(id, "/", "-", -1)
 	return path.Join(prefix, service, node)
 }
 
-func servicePath(s string) string {
+func servicePath(prefix, s string) string {
 	return path.Join(prefix, strings.Replace(s, "/", "-", -1))
 }
 
-func createPath(path string, data []byte, client *zk.Conn) error {
+func createPath(path string, data []byte, acl []zk.ACL, client *zk.Conn) error {
 	exists, _, err := client.Exists(path)
 	if err != nil {
 		return err
@@ -46,7 +49,7 @@ func createPath(path string, data []byte, client *zk.Conn) error {
 		name += v
 		e, _, _ := client.Exists(name)
 		if !e {
-			_, err = client.Create(name, []byte{}, int32(0), zk.WorldACL(zk.PermAll))
+			_, err = client.Create(name, []byte{}, int32(0), acl)
 			if err != nil {
 				return err
 			}
@@ -54,7 +57,7 @@ func createPath(path string, data []byte, client *zk.Conn) error {
 		name += "/"
 	}
 
-	_, err = client.Create(path, data, int32(0), zk.WorldACL(zk.PermAll))
+	_, err = client.Create(path, data, int32(0), acl)
 	return err
 }
 
@@ -66,3 +69,10 @@ func contains(s []string, e string) bool {
 	}
 	return false
 }
+
+// tlsDialer returns a dialer which connects to zookeeper over tls
+func tlsDialer(config *tls.Config) zk.Dialer {
+	return func(network, address string, timeout time.Duration) (net.Conn, error) {
+		return tls.DialWithDialer(&net.Dialer{Timeout: timeout}, network, address, config)
+	}
+}
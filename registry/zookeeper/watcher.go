@@ -10,6 +10,7 @@ import (
 
 type zookeeperWatcher struct {
 	client  *zk.Conn
+	prefix  string
 	stop    chan bool
 	results chan result
 }
@@ -28,6 +29,7 @@ type result struct {
 func newZookeeperWatcher(r *zookeeperRegistry) (registry.Watcher, error) {
 	zw := &zookeeperWatcher{
 		client:  r.client,
+		prefix:  r.prefix,
 		stop:    make(chan bool),
 		results: make(chan result),
 	}
@@ -65,7 +67,7 @@ func (zw *zookeeperWatcher) watchDir(key string, respChan chan watchResponse) {
 
 				newNode := path.Join(e.Path, i)
 
-				if key == prefix {
+				if key == zw.prefix {
 					// a new service was created under prefix
 					go zw.watchDir(newNode, respChan)
 
@@ -140,18 +142,18 @@ func (zw *zookeeperWatcher) watchKey(key string, respChan chan watchResponse) {
 
 func (zw *zookeeperWatcher) watch() {
 	//get all Services
-	services, _, err := zw.client.Children(prefix)
+	services, _, err := zw.client.Children(zw.prefix)
 	if err != nil {
 		zw.results <- result{nil, err}
 	}
 	respChan := make(chan watchResponse)
 
 	//watch the prefix for new child nodes
-	go zw.watchDir(prefix, respChan)
+	go zw.watchDir(zw.prefix, respChan)
 
 	//watch every service
 	for _, service := range services {
-		sPath := servicePath(service)
+		sPath := servicePath(zw.prefix, service)
 		go zw.watchDir(sPath, respChan)
 		children, _, err := zw.client.Children(sPath)
 		if err != nil {
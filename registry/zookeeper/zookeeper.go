@@ -2,7 +2,10 @@
 package zookeeper
 
 import (
+	"crypto/tls"
 	"errors"
+	"net"
+	"path"
 	"sync"
 	"time"
 
@@ -15,12 +18,15 @@ import (
 )
 
 var (
-	prefix = "/micro-registry"
+	// DefaultPrefix is the znode services are registered under
+	DefaultPrefix = "/micro-registry"
 )
 
 type zookeeperRegistry struct {
 	client  *zk.Conn
 	options registry.Options
+	prefix  string
+	acl     []zk.ACL
 	sync.Mutex
 	register map[string]uint64
 }
@@ -40,7 +46,7 @@ func (z *zookeeperRegistry) Deregister(s *registry.Service) error {
 	z.Unlock()
 
 	for _, node := range s.Nodes {
-		err := z.client.Delete(nodePath(s.Name, node.Id), -1)
+		err := z.client.Delete(nodePath(z.prefix, s.Name, node.Id), -1)
 		if err != nil {
 			return err
 		}
@@ -84,7 +90,7 @@ func (z *zookeeperRegistry) Register(s *registry.Service, opts ...registry.Regis
 
 	for _, node := range s.Nodes {
 		service.Nodes = []*registry.Node{node}
-		exists, _, err := z.client.Exists(nodePath(service.Name, node.Id))
+		exists, _, err := z.client.Exists(nodePath(z.prefix, service.Name, node.Id))
 		if err != nil {
 			return err
 		}
@@ -95,12 +101,12 @@ func (z *zookeeperRegistry) Register(s *registry.Service, opts ...registry.Regis
 		}
 
 		if exists {
-			_, err := z.client.Set(nodePath(service.Name, node.Id), srv, -1)
+			_, err := z.client.Set(nodePath(z.prefix, service.Name, node.Id), srv, -1)
 			if err != nil {
 				return err
 			}
 		} else {
-			err := createPath(nodePath(service.Name, node.Id), srv, z.client)
+			err := createPath(nodePath(z.prefix, service.Name, node.Id), srv, z.acl, z.client)
 			if err != nil {
 				return err
 			}
@@ -116,7 +122,7 @@ func (z *zookeeperRegistry) Register(s *registry.Service, opts ...registry.Regis
 }
 
 func (z *zookeeperRegistry) GetService(name string) ([]*registry.Service, error) {
-	l, _, err := z.client.Children(servicePath(name))
+	l, _, err := z.client.Children(servicePath(z.prefix, name))
 	if err != nil {
 		return nil, err
 	}
@@ -124,7 +130,7 @@ func (z *zookeeperRegistry) GetService(name string) ([]*registry.Service, error)
 	serviceMap := make(map[string]*registry.Service)
 
 	for _, n := range l {
-		_, stat, err := z.client.Children(nodePath(name, n))
+		_, stat, err := z.client.Children(nodePath(z.prefix, name, n))
 		if err != nil {
 			return nil, err
 		}
@@ -133,7 +139,7 @@ func (z *zookeeperRegistry) GetService(name string) ([]*registry.Service, error)
 			continue
 		}
 
-		b, _, err := z.client.Get(nodePath(name, n))
+		b, _, err := z.client.Get(nodePath(z.prefix, name, n))
 		if err != nil {
 			return nil, err
 		}
@@ -169,7 +175,7 @@ func (z *zookeeperRegistry) GetService(name string) ([]*registry.Service, error)
 }
 
 func (z *zookeeperRegistry) ListServices() ([]*registry.Service, error) {
-	srv, _, err := z.client.Children(prefix)
+	srv, _, err := z.client.Children(z.prefix)
 	if err != nil {
 		return nil, err
 	}
@@ -177,20 +183,20 @@ func (z *zookeeperRegistry) ListServices() ([]*registry.Service, error) {
 	serviceMap := make(map[string]*registry.Service)
 
 	for _, key := range srv {
-		s := servicePath(key)
+		s := servicePath(z.prefix, key)
 		nodes, _, err := z.client.Children(s)
 		if err != nil {
 			return nil, err
 		}
 
 		for _, node := range nodes {
-			_, stat, err := z.client.Children(nodePath(key, node))
+			_, stat, err := z.client.Children(nodePath(z.prefix, key, node))
 			if err != nil {
 				return nil, err
 			}
 
 			if stat.NumChildren == 0 {
-				b, _, err := z.client.Get(nodePath(key, node))
+				b, _, err := z.client.Get(nodePath(z.prefix, key, node))
 				if err != nil {
 					return nil, err
 				}
@@ -220,6 +226,48 @@ func (z *zookeeperRegistry) Watch() (registry.Watcher, error) {
 	return newZookeeperWatcher(z)
 }
 
+// config is the zookeeper specific configuration of the registry
+type config struct {
+	prefix string
+	acl    []zk.ACL
+	auth   []authInfo
+	dialer zk.Dialer
+}
+
+// configure reads the config from the options
+func configure(options registry.Options) config {
+	conf := config{
+		prefix: DefaultPrefix,
+		acl:    zk.WorldACL(zk.PermAll),
+		dialer: zk.Dialer(net.DialTimeout),
+	}
+
+	if options.Context != nil {
+		if p, ok := options.Context.Value(prefixKey{}).(string); ok && len(p) > 0 {
+			conf.prefix = p
+		}
+		if a, ok := options.Context.Value(aclKey{}).([]zk.ACL); ok && len(a) > 0 {
+			conf.acl = a
+		}
+		conf.auth, _ = options.Context.Value(authKey{}).([]authInfo)
+	}
+
+	// the prefix is compared with the cleaned paths of znodes
+	conf.prefix = path.Join("/", conf.prefix)
+
+	if options.Secure || options.TLSConfig != nil {
+		tlsConfig := options.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{
+				InsecureSkipVerify: true,
+			}
+		}
+		conf.dialer = tlsDialer(tlsConfig)
+	}
+
+	return conf
+}
+
 func NewRegistry(opts ...registry.Option) registry.Registry {
 	var options registry.Options
 	for _, o := range opts {
@@ -242,20 +290,31 @@ func NewRegistry(opts ...registry.Option) registry.Registry {
 		cAddrs = []string{"127.0.0.1:2181"}
 	}
 
+	conf := configure(options)
+
 	// connect to zookeeper
-	c, _, err := zk.Connect(cAddrs, time.Second*options.Timeout)
+	c, _, err := zk.Connect(cAddrs, time.Second*options.Timeout, zk.WithDialer(conf.dialer))
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	// authenticate the session, credentials are sent again on reconnect
+	for _, a := range conf.auth {
+		if err := c.AddAuth(a.scheme, a.auth); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	// create our prefix path
-	if err := createPath(prefix, []byte{}, c); err != nil {
+	if err := createPath(conf.prefix, []byte{}, conf.acl, c); err != nil {
 		log.Fatal(err)
 	}
 
 	return &zookeeperRegistry{
 		client:   c,
 		options:  options,
+		prefix:   conf.prefix,
+		acl:      conf.acl,
 		register: make(map[string]uint64),
 	}
 }
@@ -0,0 +1,115 @@
+package zookeeper
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+func options(opts ...registry.Option) registry.Options {
+	var options registry.Options
+	for _, o := range opts {
+		o(&options)
+	}
+	return options
+}
+
+func TestPrefix(t *testing.T) {
+	testData := []struct {
+		prefix   string
+		expected string
+	}{
+		{"", DefaultPrefix},
+		{"/foo", "/foo"},
+		{"/foo/", "/foo"},
+		{"foo", "/foo"},
+		{"/foo//bar/", "/foo/bar"},
+	}
+
+	for _, d := range testData {
+		conf := configure(options(Prefix(d.prefix)))
+		if conf.prefix != d.expected {
+			t.Fatalf("Expected prefix %s for %q, got %s", d.expected, d.prefix, conf.prefix)
+		}
+
+		// the watcher compares the prefix with the parent of service paths
+		if dir := path.Dir(servicePath(conf.prefix, "go.micro.srv.foo")); dir != conf.prefix {
+			t.Fatalf("Expected services under %s, got %s", conf.prefix, dir)
+		}
+	}
+}
+
+func TestPaths(t *testing.T) {
+	if p := servicePath("/micro", "foo/bar"); p != "/micro/foo-bar" {
+		t.Fatalf("Expected /micro/foo-bar, got %s", p)
+	}
+
+	if p := nodePath("/micro", "foo/bar", "foo/1"); p != "/micro/foo-bar/foo-1" {
+		t.Fatalf("Expected /micro/foo-bar/foo-1, got %s", p)
+	}
+}
+
+func TestACL(t *testing.T) {
+	conf := configure(options())
+	if len(conf.acl) != 1 || conf.acl[0].Perms != zk.PermAll || conf.acl[0].Scheme != "world" {
+		t.Fatalf("Expected a world acl by default, got %+v", conf.acl)
+	}
+
+	acl := zk.DigestACL(zk.PermRead, "user", "password")
+
+	conf = configure(options(ACL(acl...)))
+	if len(conf.acl) != 1 || conf.acl[0] != acl[0] {
+		t.Fatalf("Expected acl %+v, got %+v", acl, conf.acl)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	conf := configure(options(Auth("ip", []byte("127.0.0.1")), DigestAuth("user", "password")))
+
+	expected := []authInfo{
+		{"ip", []byte("127.0.0.1")},
+		{"digest", []byte("user:password")},
+	}
+
+	if len(conf.auth) != len(expected) {
+		t.Fatalf("Expected auth %+v, got %+v", expected, conf.auth)
+	}
+
+	for i, a := range conf.auth {
+		if a.scheme != expected[i].scheme || string(a.auth) != string(expected[i].auth) {
+			t.Fatalf("Expected auth %+v, got %+v", expected, conf.auth)
+		}
+	}
+}
+
+func TestTLSDialer(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer s.Close()
+
+	addr := s.Listener.Addr().String()
+
+	// secure without a config skips verification
+	conf := configure(options(registry.Secure(true)))
+
+	c, err := conf.dialer("tcp", addr, time.Second)
+	if err != nil {
+		t.Fatalf("Expected tls connection, got %v", err)
+	}
+	if _, ok := c.(*tls.Conn); !ok {
+		t.Fatalf("Expected tls connection, got %T", c)
+	}
+	c.Close()
+
+	// a config is used as given
+	conf = configure(options(registry.TLSConfig(&tls.Config{})))
+
+	if _, err := conf.dialer("tcp", addr, time.Second); err == nil {
+		t.Fatal("Expected the self signed certificate to be rejected")
+	}
+}
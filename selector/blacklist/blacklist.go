@@ -8,6 +8,13 @@ import (
 	"github.com/micro/go-micro/selector"
 )
 
+// Selector is a blacklist selector which exposes the ejection state of nodes
+type Selector interface {
+	selector.Selector
+	// Ejections returns the outlier detection state of the nodes
+	Ejections() []Ejection
+}
+
 type blacklistSelector struct {
 	so   selector.Options
 	exit chan bool
@@ -22,6 +29,7 @@ func (r *blacklistSelector) Init(opts ...selector.Option) error {
 	for _, o := range opts {
 		o(&r.so)
 	}
+	r.bl.setOptions(newOptions(r.so.Context))
 	return nil
 }
 
@@ -71,6 +79,10 @@ func (r *blacklistSelector) Reset(service string) {
 	r.bl.Reset(service)
 }
 
+func (r *blacklistSelector) Ejections() []Ejection {
+	return r.bl.Ejections()
+}
+
 func (r *blacklistSelector) Close() error {
 	select {
 	case <-r.exit:
@@ -102,10 +114,11 @@ func newSelector(opts ...selector.Option) selector.Selector {
 	return &blacklistSelector{
 		so:   sopts,
 		exit: make(chan bool),
-		bl:   newBlacklist(),
+		bl:   newBlacklist(newOptions(sopts.Context)),
 	}
 }
 
+// NewSelector returns a blacklist selector, which implements Selector
 func NewSelector(opts ...selector.Option) selector.Selector {
 	return newSelector(opts...)
 }
@@ -105,3 +105,57 @@ func TestBlackList(t *testing.T) {
 	}
 
 }
+
+func TestMaxEjectionPercent(t *testing.T) {
+	r := mock.NewRegistry()
+
+	nodes := []*registry.Node{
+		&registry.Node{
+			Id:      "test-1",
+			Address: "localhost",
+			Port:    10001,
+		},
+		&registry.Node{
+			Id:      "test-2",
+			Address: "localhost",
+			Port:    10002,
+		},
+	}
+
+	r.Register(&registry.Service{
+		Name:  "test",
+		Nodes: nodes,
+	})
+
+	rs := NewSelector(selector.Registry(r), Consecutive(1), MaxEjectionPercent(50)).(Selector)
+	defer rs.Close()
+
+	// eject all of it
+	for _, node := range nodes {
+		rs.Mark("test", node, errors.New("error"))
+	}
+
+	ejections := rs.Ejections()
+	if len(ejections) != 2 || !ejections[0].Ejected || !ejections[1].Ejected {
+		t.Fatalf("Expected both nodes ejected got %+v", ejections)
+	}
+
+	// the cap leaves one node
+	next, err := rs.Select("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		node, err := next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[node.Id] = true
+	}
+
+	if len(seen) != 1 {
+		t.Fatalf("Expected seen to be 1 %+v", seen)
+	}
+}
@@ -1,2 +1,9 @@
 // Package blacklist is a selector which includes blacklisting of nodes when they fail
 package blacklist
+
+/*
+	Nodes are ejected, much like Envoy's outlier detection, after consecutive
+	errors or when their error rate in an interval is exceeded. The ejection
+	time doubles for repeat offenders and decays while they're healthy.
+	A max ejection percent keeps some nodes of a service available.
+*/
@@ -2,87 +2,198 @@ package blacklist
 
 import (
 	"math/rand"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/micro/go-micro/registry"
 )
 
+// Ejection is the outlier detection state of a node
+type Ejection struct {
+	Id      string
+	Service string
+	// Ejected is true while the node is ejected
+	Ejected bool
+	// Until is when the node's last ejection ends
+	Until time.Time
+	// Ejections multiply the ejection time, they decay while the node is healthy
+	Ejections         int
+	ConsecutiveErrors int
+	// Requests and Errors in the current interval
+	Requests int
+	Errors   int
+}
+
 type node struct {
-	age     time.Time
 	id      string
 	service string
-	count   int
+	// consecutive errors
+	count int
+	// requests and errors in the current interval
+	requests int
+	errors   int
+	// times ejected
+	ejections int
+	// start and end of the last ejection
+	since time.Time
+	age   time.Time
+}
+
+type options struct {
+	// consecutive errors before ejecting
+	consecutive int
+	// error rate in an interval before ejecting, zero is disabled
+	errorRate float64
+	// requests in an interval before the error rate applies
+	minRequests int
+	// base and max ejection time
+	ejection    time.Duration
+	maxEjection time.Duration
+	// max percent of the nodes of a service ejected
+	maxPercent int
 }
 
 type blacklist struct {
-	ttl  int
 	exit chan bool
+	// signals run the interval changed
+	update chan bool
 
 	sync.RWMutex
-	bl map[string]node
+	opts options
+	bl   map[string]*node
 }
 
 var (
 	// number of times we see an error before blacklisting
 	count = 3
 
-	// the ttl to blacklist for
+	// the ttl to blacklist for, doubled for repeat offenders
 	ttl = 30
+
+	// the max ttl to blacklist for
+	maxTTL = 300
 )
 
 func init() {
 	rand.Seed(time.Now().Unix())
 }
 
+func defaultOptions() options {
+	return options{
+		consecutive: count,
+		ejection:    time.Duration(ttl) * time.Second,
+		maxEjection: time.Duration(maxTTL) * time.Second,
+		maxPercent:  100,
+	}
+}
+
+func (n *node) ejected(now time.Time) bool {
+	return now.Before(n.age)
+}
+
+func (n *node) ejection(now time.Time) Ejection {
+	return Ejection{
+		Id:                n.id,
+		Service:           n.service,
+		Ejected:           n.ejected(now),
+		Until:             n.age,
+		Ejections:         n.ejections,
+		ConsecutiveErrors: n.count,
+		Requests:          n.requests,
+		Errors:            n.errors,
+	}
+}
+
+// purge starts a new interval, decaying the ejections of healthy nodes
 func (r *blacklist) purge() {
 	now := time.Now()
 	r.Lock()
 	for k, v := range r.bl {
-		if d := v.age.Sub(now); d.Seconds() < 0 {
+		if v.ejected(now) {
+			continue
+		}
+
+		v.requests = 0
+		v.errors = 0
+
+		// healthy for an interval since the last ejection ended
+		if v.ejections > 0 && now.Sub(v.age) >= r.opts.ejection {
+			v.ejections--
+		}
+
+		if v.ejections == 0 && v.count == 0 {
 			delete(r.bl, k)
 		}
 	}
 	r.Unlock()
 }
 
+// run purges every interval, which is the ejection time
 func (r *blacklist) run() {
-	t := time.NewTicker(time.Duration(r.ttl) * time.Second)
+	t := time.NewTicker(r.interval())
 
 	for {
 		select {
 		case <-r.exit:
 			t.Stop()
 			return
+		case <-r.update:
+			t.Stop()
+			t = time.NewTicker(r.interval())
 		case <-t.C:
 			r.purge()
 		}
 	}
 }
 
+func (r *blacklist) interval() time.Duration {
+	r.RLock()
+	defer r.RUnlock()
+	return r.opts.ejection
+}
+
+// Filter removes the ejected nodes, keeping the nodes ejected last
+// once the max percent of the nodes of the service are ejected
 func (r *blacklist) Filter(services []*registry.Service) ([]*registry.Service, error) {
-	var viableServices []*registry.Service
+	now := time.Now()
 
 	r.RLock()
 
+	var total int
+	var ejected []*node
+
+	for _, service := range services {
+		for _, n := range service.Nodes {
+			total++
+			if bn, ok := r.bl[n.Id]; ok && bn.ejected(now) {
+				ejected = append(ejected, bn)
+			}
+		}
+	}
+
+	// eject in the order nodes were ejected up to the max percent
+	sort.Slice(ejected, func(i, j int) bool { return ejected[i].since.Before(ejected[j].since) })
+
+	skip := make(map[string]bool)
+	for _, n := range ejected {
+		if len(skip)*100/total >= r.opts.maxPercent {
+			break
+		}
+		skip[n.id] = true
+	}
+
+	r.RUnlock()
+
+	var viableServices []*registry.Service
+
 	for _, service := range services {
 		var viableNodes []*registry.Node
 
 		for _, node := range service.Nodes {
-			n, ok := r.bl[node.Id]
-			if !ok {
-				// blacklist miss so add it
-				viableNodes = append(viableNodes, node)
+			if skip[node.Id] {
 				continue
 			}
-
-			// got some blacklist info
-			// skip the node if it exceeds count
-			if n.count >= count {
-				continue
-			}
-
-			// doesn't exceed count, still viable
 			viableNodes = append(viableNodes, node)
 		}
 
@@ -96,38 +207,62 @@ func (r *blacklist) Filter(services []*registry.Service) ([]*registry.Service, e
 		viableServices = append(viableServices, viableService)
 	}
 
-	r.RUnlock()
-
 	return viableServices, nil
 }
 
+// Mark ejects the node after consecutive errors or when the error rate is
+// exceeded, for the ejection time doubled for every recent ejection
 func (r *blacklist) Mark(service string, nod *registry.Node, err error) {
 	r.Lock()
 	defer r.Unlock()
 
-	// reset when error is nil
-	// basically closing the circuit
-	if err == nil {
-		delete(r.bl, nod.Id)
-		return
-	}
-
 	n, ok := r.bl[nod.Id]
 	if !ok {
-		n = node{
+		n = &node{
 			id:      nod.Id,
 			service: service,
 		}
+		r.bl[nod.Id] = n
+	}
+
+	// requests to an ejected node don't count
+	// towards its next ejection
+	now := time.Now()
+	if n.ejected(now) {
+		return
 	}
 
-	// mark it
+	n.requests++
+
+	// reset when error is nil
+	// basically closing the circuit
+	if err == nil {
+		n.count = 0
+		return
+	}
+
+	n.errors++
 	n.count++
 
-	// set age to ttl seconds in future
-	n.age = time.Now().Add(time.Duration(r.ttl) * time.Second)
+	rate := r.opts.errorRate > 0 && n.requests >= r.opts.minRequests &&
+		float64(n.errors)/float64(n.requests) >= r.opts.errorRate
 
-	// save
-	r.bl[nod.Id] = n
+	if n.count < r.opts.consecutive && !rate {
+		return
+	}
+
+	// eject it
+	d := r.opts.ejection << uint(n.ejections)
+	if d > r.opts.maxEjection || d <= 0 {
+		d = r.opts.maxEjection
+	}
+
+	n.ejections++
+	n.since = now
+	n.age = now.Add(d)
+	n.count = 0
+	n.requests = 0
+	n.errors = 0
 }
 
 func (r *blacklist) Reset(service string) {
@@ -142,6 +277,41 @@ func (r *blacklist) Reset(service string) {
 	}
 }
 
+// setOptions updates the options, restarting purge if the interval changed
+func (r *blacklist) setOptions(opts options) {
+	r.Lock()
+	changed := r.opts.ejection != opts.ejection
+	r.opts = opts
+	r.Unlock()
+
+	if !changed {
+		return
+	}
+
+	select {
+	case r.update <- true:
+	default:
+		// an update is already pending
+	}
+}
+
+// Ejections returns the state of the nodes
+func (r *blacklist) Ejections() []Ejection {
+	now := time.Now()
+
+	r.RLock()
+	defer r.RUnlock()
+
+	var ejections []Ejection
+	for _, n := range r.bl {
+		ejections = append(ejections, n.ejection(now))
+	}
+
+	sort.Slice(ejections, func(i, j int) bool { return ejections[i].Id < ejections[j].Id })
+
+	return ejections
+}
+
 func (r *blacklist) Close() error {
 	select {
 	case <-r.exit:
@@ -152,11 +322,12 @@ func (r *blacklist) Close() error {
 	return nil
 }
 
-func newBlacklist() *blacklist {
+func newBlacklist(opts options) *blacklist {
 	bl := &blacklist{
-		ttl:  ttl,
-		bl:   make(map[string]node),
-		exit: make(chan bool),
+		opts:   opts,
+		bl:     make(map[string]*node),
+		exit:   make(chan bool),
+		update: make(chan bool, 1),
 	}
 
 	go bl.run()
@@ -9,13 +9,10 @@ import (
 )
 
 func TestBlackListFilter(t *testing.T) {
-	bl := &blacklist{
-		ttl:  1,
-		bl:   make(map[string]node),
-		exit: make(chan bool),
-	}
+	opts := defaultOptions()
+	opts.ejection = time.Second
 
-	go bl.run()
+	bl := newBlacklist(opts)
 	defer bl.Close()
 
 	services := []*registry.Service{
@@ -74,7 +71,7 @@ func TestBlackListFilter(t *testing.T) {
 	blacklistTest := func() {
 		// test blacklisting
 		// mark until failure
-		for i := 0; i < count+1; i++ {
+		for i := 0; i < opts.consecutive+1; i++ {
 			for _, node := range services[0].Nodes {
 				bl.Mark("foo", node, errors.New("blacklist"))
 			}
@@ -91,7 +88,7 @@ func TestBlackListFilter(t *testing.T) {
 	}
 
 	// sleep the ttl duration
-	time.Sleep(time.Second * time.Duration(bl.ttl) * 2)
+	time.Sleep(opts.ejection * 2)
 
 	// now run filterTest again
 	filterTest()
@@ -105,3 +102,172 @@ func TestBlackListFilter(t *testing.T) {
 	// check again
 	filterTest()
 }
+
+func TestBlackListBackoff(t *testing.T) {
+	opts := defaultOptions()
+	opts.ejection = time.Minute
+	opts.maxEjection = time.Minute * 3
+
+	bl := newBlacklist(opts)
+	defer bl.Close()
+
+	node := &registry.Node{Id: "foo-1"}
+
+	// the ejection time doubles up to the max
+	for _, d := range []time.Duration{time.Minute, time.Minute * 2, time.Minute * 3, time.Minute * 3} {
+		for i := 0; i < opts.consecutive; i++ {
+			bl.Mark("foo", node, errors.New("backoff"))
+		}
+
+		n := bl.bl[node.Id]
+		if got := n.age.Sub(n.since); got != d {
+			t.Fatalf("Expected ejection of %v got %v", d, got)
+		}
+
+		// end the ejection
+		n.age = time.Now().Add(-time.Second)
+	}
+
+	if e := bl.Ejections(); len(e) != 1 || e[0].Ejections != 4 || e[0].Ejected {
+		t.Fatalf("Expected 4 ejections of a readmitted node got %+v", e)
+	}
+
+	// healthy for an interval decays the ejections
+	bl.bl[node.Id].age = time.Now().Add(-opts.ejection)
+	bl.purge()
+
+	if e := bl.Ejections(); len(e) != 1 || e[0].Ejections != 3 {
+		t.Fatalf("Expected 3 ejections got %+v", e)
+	}
+}
+
+func TestBlackListEjectedMarks(t *testing.T) {
+	opts := defaultOptions()
+
+	bl := newBlacklist(opts)
+	defer bl.Close()
+
+	node := &registry.Node{Id: "foo-1"}
+
+	for i := 0; i < opts.consecutive; i++ {
+		bl.Mark("foo", node, errors.New("eject"))
+	}
+
+	// requests in flight when ejected finish
+	bl.Mark("foo", node, nil)
+	for i := 0; i < opts.consecutive; i++ {
+		bl.Mark("foo", node, errors.New("ejected"))
+	}
+
+	e := bl.Ejections()
+	if len(e) != 1 || !e[0].Ejected || e[0].Ejections != 1 {
+		t.Fatalf("Expected 1 ejection got %+v", e)
+	}
+
+	if e[0].ConsecutiveErrors != 0 || e[0].Requests != 0 || e[0].Errors != 0 {
+		t.Fatalf("Expected no requests counted while ejected got %+v", e)
+	}
+
+	// end the ejection, an error isn't enough to eject it again
+	bl.bl[node.Id].age = time.Now().Add(-time.Second)
+	bl.Mark("foo", node, errors.New("readmitted"))
+
+	if e := bl.Ejections(); e[0].Ejected || e[0].ConsecutiveErrors != 1 {
+		t.Fatalf("Expected readmitted node with 1 error got %+v", e)
+	}
+}
+
+func TestBlackListInterval(t *testing.T) {
+	opts := defaultOptions()
+	opts.ejection = time.Hour
+
+	bl := newBlacklist(opts)
+	defer bl.Close()
+
+	bl.Mark("foo", &registry.Node{Id: "foo-1"}, nil)
+
+	// purge restarts at the new ejection time
+	opts.ejection = time.Millisecond * 10
+	bl.setOptions(opts)
+
+	time.Sleep(time.Millisecond * 50)
+
+	if e := bl.Ejections(); len(e) != 0 {
+		t.Fatalf("Expected the healthy node to be purged got %+v", e)
+	}
+}
+
+func TestBlackListErrorRate(t *testing.T) {
+	opts := defaultOptions()
+	opts.errorRate = 0.5
+	opts.minRequests = 10
+
+	bl := newBlacklist(opts)
+	defer bl.Close()
+
+	node := &registry.Node{Id: "foo-1"}
+
+	// errors which are never consecutive
+	for i := 0; i < 9; i++ {
+		var err error
+		if i%2 == 0 {
+			err = errors.New("rate")
+		}
+		bl.Mark("foo", node, err)
+	}
+
+	if e := bl.Ejections(); e[0].Ejected {
+		t.Fatalf("Expected node under min requests to not be ejected %+v", e)
+	}
+
+	bl.Mark("foo", node, nil)
+	bl.Mark("foo", node, errors.New("rate"))
+
+	if e := bl.Ejections(); !e[0].Ejected {
+		t.Fatalf("Expected node over the error rate to be ejected %+v", e)
+	}
+}
+
+func TestBlackListMaxEjectionPercent(t *testing.T) {
+	opts := defaultOptions()
+	opts.maxPercent = 50
+
+	bl := newBlacklist(opts)
+	defer bl.Close()
+
+	services := []*registry.Service{
+		&registry.Service{
+			Name: "foo",
+			Nodes: []*registry.Node{
+				&registry.Node{Id: "foo-1"},
+				&registry.Node{Id: "foo-2"},
+				&registry.Node{Id: "foo-3"},
+				&registry.Node{Id: "foo-4"},
+			},
+		},
+	}
+
+	for _, node := range services[0].Nodes {
+		for i := 0; i < opts.consecutive; i++ {
+			bl.Mark("foo", node, errors.New("eject"))
+		}
+		// order the ejections
+		time.Sleep(time.Millisecond)
+	}
+
+	filtered, err := bl.Filter(services)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first two ejected stay ejected
+	if len(filtered) != 1 || len(filtered[0].Nodes) != 2 {
+		t.Fatalf("Expected 2 nodes got %+v", filtered)
+	}
+
+	for i, node := range filtered[0].Nodes {
+		if id := services[0].Nodes[i+2].Id; node.Id != id {
+			t.Fatalf("Expected %s got %s", id, node.Id)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package blacklist
+
+import (
+	"time"
+
+	"github.com/micro/go-micro/selector"
+	"golang.org/x/net/context"
+)
+
+type consecutiveKey struct{}
+type errorRateKey struct{}
+type ejectionTimeKey struct{}
+type maxEjectionPercentKey struct{}
+
+type errorRate struct {
+	rate        float64
+	minRequests int
+}
+
+type ejectionTime struct {
+	base time.Duration
+	max  time.Duration
+}
+
+// Consecutive sets the number of errors in a row before a node is ejected, by default 3
+func Consecutive(n int) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, consecutiveKey{}, n)
+	}
+}
+
+// ErrorRate ejects a node when the ratio of errors to requests in an interval
+// reaches rate e.g 0.5, once it's seen minRequests. Disabled by default.
+func ErrorRate(rate float64, minRequests int) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, errorRateKey{}, errorRate{rate, minRequests})
+	}
+}
+
+// EjectionTime sets the time a node is first ejected for, which is also the
+// interval. It's doubled for every recent ejection up to max. By default 30s and 5m.
+func EjectionTime(base, max time.Duration) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, ejectionTimeKey{}, ejectionTime{base, max})
+	}
+}
+
+// MaxEjectionPercent caps the percent of the nodes of a service which
+// can be ejected. At least one node is always ejectable. By default 100.
+func MaxEjectionPercent(p int) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, maxEjectionPercentKey{}, p)
+	}
+}
+
+func newOptions(ctx context.Context) options {
+	opts := defaultOptions()
+	if ctx == nil {
+		return opts
+	}
+
+	if n, ok := ctx.Value(consecutiveKey{}).(int); ok && n > 0 {
+		opts.consecutive = n
+	}
+
+	if e, ok := ctx.Value(errorRateKey{}).(errorRate); ok {
+		opts.errorRate = e.rate
+		opts.minRequests = e.minRequests
+	}
+
+	if e, ok := ctx.Value(ejectionTimeKey{}).(ejectionTime); ok && e.base > 0 {
+		opts.ejection = e.base
+		opts.maxEjection = e.max
+		if opts.maxEjection < opts.ejection {
+			opts.maxEjection = opts.ejection
+		}
+	}
+
+	if p, ok := ctx.Value(maxEjectionPercentKey{}).(int); ok && p > 0 {
+		opts.maxPercent = p
+	}
+
+	return opts
+}
@@ -0,0 +1,217 @@
+package canary
+
+import (
+	"hash/crc32"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/cmd"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+
+	"golang.org/x/net/context"
+)
+
+// Selector is a canary selector whose split can be changed at runtime
+type Selector interface {
+	selector.Selector
+	// Split returns the weight of each version
+	Split() map[string]int
+	// SetSplit replaces the weight of each version
+	SetSplit(map[string]int)
+}
+
+type canarySelector struct {
+	so selector.Options
+
+	sync.RWMutex
+	split map[string]int
+}
+
+func init() {
+	rand.Seed(time.Now().UnixNano())
+	cmd.DefaultSelectors["canary"] = NewSelector
+}
+
+// versions groups the services with nodes by version
+func versions(services []*registry.Service) map[string][]*registry.Service {
+	byVersion := make(map[string][]*registry.Service)
+	for _, service := range services {
+		if len(service.Nodes) == 0 {
+			continue
+		}
+		byVersion[service.Version] = append(byVersion[service.Version], service)
+	}
+	return byVersion
+}
+
+// pick returns the version of the key, or a random one without a key,
+// out of the versions in the split which have nodes
+func pick(split map[string]int, available map[string][]*registry.Service, key string) (string, bool) {
+	var names []string
+	var total int
+
+	for version, weight := range split {
+		if weight <= 0 || len(available[version]) == 0 {
+			continue
+		}
+		names = append(names, version)
+		total += weight
+	}
+
+	if total == 0 {
+		return "", false
+	}
+
+	// ordered so keys keep their version while the split is unchanged
+	sort.Strings(names)
+
+	var b int
+	if len(key) > 0 {
+		b = int(crc32.ChecksumIEEE([]byte(key)) % uint32(total))
+	} else {
+		b = rand.Intn(total)
+	}
+
+	for _, version := range names {
+		if b < split[version] {
+			return version, true
+		}
+		b -= split[version]
+	}
+
+	return names[len(names)-1], true
+}
+
+func (c *canarySelector) Init(opts ...selector.Option) error {
+	for _, o := range opts {
+		o(&c.so)
+	}
+
+	// only a split set by these options replaces the current one,
+	// which may have been changed with SetSplit since
+	set := selector.Options{Context: context.Background()}
+	for _, o := range opts {
+		o(&set)
+	}
+
+	if s, ok := set.Context.Value(splitKey{}).(map[string]int); ok {
+		c.SetSplit(s)
+	}
+
+	return nil
+}
+
+func (c *canarySelector) Options() selector.Options {
+	return c.so
+}
+
+func (c *canarySelector) Select(service string, opts ...selector.SelectOption) (selector.Next, error) {
+	sopts := selector.SelectOptions{
+		Context: context.TODO(),
+	}
+
+	for _, opt := range opts {
+		opt(&sopts)
+	}
+
+	// get the service
+	services, err := c.so.Registry.GetService(service)
+	if err != nil {
+		return nil, err
+	}
+
+	// apply the filters
+	for _, filter := range sopts.Filters {
+		services = filter(services)
+	}
+
+	// if there's nothing left, return
+	if len(services) == 0 {
+		return nil, selector.ErrNotFound
+	}
+
+	available := versions(services)
+
+	// any nodes left?
+	if len(available) == 0 {
+		return nil, selector.ErrNoneAvailable
+	}
+
+	key, _ := sopts.Context.Value(keyKey{}).(string)
+
+	// every version if none in the split have nodes
+	c.RLock()
+	version, ok := pick(c.split, available, key)
+	c.RUnlock()
+
+	if ok {
+		services = available[version]
+	}
+
+	// a strategy for the call picks from the version
+	if sopts.Strategy != nil {
+		return sopts.Strategy(services), nil
+	}
+
+	var nodes []*registry.Node
+
+	// flatten node list
+	for _, service := range services {
+		nodes = append(nodes, service.Nodes...)
+	}
+
+	return func() (*registry.Node, error) {
+		return nodes[rand.Int()%len(nodes)], nil
+	}, nil
+}
+
+func (c *canarySelector) Mark(service string, node *registry.Node, err error) {
+	return
+}
+
+func (c *canarySelector) Reset(service string) {
+	return
+}
+
+func (c *canarySelector) Split() map[string]int {
+	c.RLock()
+	defer c.RUnlock()
+	return copySplit(c.split)
+}
+
+func (c *canarySelector) SetSplit(s map[string]int) {
+	s = copySplit(s)
+	c.Lock()
+	c.split = s
+	c.Unlock()
+}
+
+func (c *canarySelector) Close() error {
+	return nil
+}
+
+func (c *canarySelector) String() string {
+	return "canary"
+}
+
+// NewSelector returns a canary selector, which implements Selector
+func NewSelector(opts ...selector.Option) selector.Selector {
+	sopts := selector.Options{
+		Context:  context.TODO(),
+		Registry: registry.DefaultRegistry,
+	}
+
+	for _, opt := range opts {
+		opt(&sopts)
+	}
+
+	split, _ := sopts.Context.Value(splitKey{}).(map[string]int)
+
+	return &canarySelector{
+		so:    sopts,
+		split: copySplit(split),
+	}
+}
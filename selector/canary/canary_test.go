@@ -0,0 +1,122 @@
+package canary
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+	"github.com/micro/go-plugins/registry/memory"
+)
+
+func testSelector(opts ...selector.Option) Selector {
+	r := memory.NewRegistry()
+
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		r.Register(&registry.Service{
+			Name:    "foo",
+			Version: version,
+			Nodes: []*registry.Node{
+				&registry.Node{
+					Id:      "foo-" + version,
+					Address: "localhost",
+					Port:    8080,
+				},
+			},
+		})
+	}
+
+	opts = append(opts, selector.Registry(r))
+	return NewSelector(opts...).(Selector)
+}
+
+func picks(t *testing.T, s selector.Selector, n int, opts ...selector.SelectOption) map[string]int {
+	counts := make(map[string]int)
+
+	for i := 0; i < n; i++ {
+		next, err := s.Select("foo", opts...)
+		if err != nil {
+			t.Fatalf("Unexpected error calling select: %v", err)
+		}
+
+		node, err := next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[node.Id]++
+	}
+
+	return counts
+}
+
+func TestSplit(t *testing.T) {
+	s := testSelector(Split(map[string]int{"1.0.0": 90, "1.1.0": 10}))
+
+	counts := picks(t, s, 1000)
+	if c := counts["foo-1.1.0"]; c < 50 || c > 150 {
+		t.Fatalf("Expected about 100 picks of the canary got %+v", counts)
+	}
+
+	// change the split at runtime
+	s.SetSplit(map[string]int{"1.1.0": 100})
+
+	if c := picks(t, s, 10); c["foo-1.1.0"] != 10 {
+		t.Fatalf("Expected every pick of the canary got %+v", c)
+	}
+
+	if split := s.Split(); len(split) != 1 || split["1.1.0"] != 100 {
+		t.Fatalf("Expected the new split got %+v", split)
+	}
+
+	// other options keep the split
+	s.Init(selector.Registry(s.Options().Registry))
+
+	if split := s.Split(); len(split) != 1 || split["1.1.0"] != 100 {
+		t.Fatalf("Expected the split to be kept got %+v", split)
+	}
+
+	s.Init(Split(map[string]int{"1.0.0": 100}))
+
+	if c := picks(t, s, 10); c["foo-1.0.0"] != 10 {
+		t.Fatalf("Expected every pick of the stable version got %+v", c)
+	}
+}
+
+func TestSplitUnavailable(t *testing.T) {
+	// versions without nodes are left out
+	s := testSelector(Split(map[string]int{"1.0.0": 50, "2.0.0": 50}))
+
+	if c := picks(t, s, 10); c["foo-1.0.0"] != 10 {
+		t.Fatalf("Expected every pick of the available version got %+v", c)
+	}
+
+	// every version when none in the split have nodes
+	s.SetSplit(map[string]int{"2.0.0": 100})
+
+	if c := picks(t, s, 100); len(c) != 2 {
+		t.Fatalf("Expected picks of every version got %+v", c)
+	}
+}
+
+func TestSticky(t *testing.T) {
+	s := testSelector(Split(map[string]int{"1.0.0": 50, "1.1.0": 50}))
+
+	seen := make(map[string]bool)
+
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("user-%d", i)
+
+		c := picks(t, s, 10, Key(key))
+		if len(c) != 1 {
+			t.Fatalf("Expected %s to stick to a version got %+v", key, c)
+		}
+
+		for id := range c {
+			seen[id] = true
+		}
+	}
+
+	if len(seen) != 2 {
+		t.Fatalf("Expected keys on every version got %+v", seen)
+	}
+}
@@ -0,0 +1,14 @@
+// Package canary is a selector which splits traffic between the versions of a service.
+package canary
+
+/*
+   A canary selector. Requests are split between the versions of a service by weight, for example
+   95 to version 1.0.0 and 5 to version 1.1.0, rather than the nodes of every version being flattened.
+   Versions which aren't in the split get no requests unless no version in it has nodes.
+
+   Assignment is sticky when a request has a key. The key is hashed to pick the version so the same
+   user stays on the same version while the split is unchanged. The key is set per call with the Key
+   select option, or taken from the request metadata by the client wrapper.
+
+   The split can be changed at runtime with SetSplit or by calling Init with a new split.
+*/
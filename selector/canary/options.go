@@ -0,0 +1,37 @@
+package canary
+
+import (
+	"github.com/micro/go-micro/selector"
+	"golang.org/x/net/context"
+)
+
+type splitKey struct{}
+type keyKey struct{}
+
+// Split sets the weight of each version e.g {"1.0.0": 95, "1.1.0": 5}
+func Split(s map[string]int) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, splitKey{}, copySplit(s))
+	}
+}
+
+// Key sets the key of the call which sticks it to a version
+func Key(k string) selector.SelectOption {
+	return func(o *selector.SelectOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, keyKey{}, k)
+	}
+}
+
+func copySplit(s map[string]int) map[string]int {
+	c := make(map[string]int, len(s))
+	for k, v := range s {
+		c[k] = v
+	}
+	return c
+}
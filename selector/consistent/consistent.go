@@ -0,0 +1,304 @@
+package consistent
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/cmd"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+	"github.com/micro/go-plugins/registry/cache"
+
+	"golang.org/x/net/context"
+)
+
+type consistentSelector struct {
+	so selector.Options
+
+	sync.Mutex
+	// rc caches the services of the registry
+	rc cache.Cache
+	// the ring of each service
+	rings map[string]*ring
+	// requests in flight to each node
+	// requests in flight to the nodes of each service
+	loads map[string]map[string]int
+}
+
+var (
+	// DefaultReplicas is the number of virtual nodes of each node
+	DefaultReplicas = 100
+	// DefaultLoadFactor bounds the requests in flight to a node to 1.25 times the average
+	DefaultLoadFactor = 1.25
+)
+
+func init() {
+	rand.Seed(time.Now().UnixNano())
+	cmd.DefaultSelectors["consistent"] = NewSelector
+}
+
+func (c *consistentSelector) replicas() int {
+	if n, ok := c.so.Context.Value(replicasKey{}).(int); ok && n > 0 {
+		return n
+	}
+	return DefaultReplicas
+}
+
+func (c *consistentSelector) loadFactor() float64 {
+	if f, ok := c.so.Context.Value(loadFactorKey{}).(float64); ok && f >= 1 {
+		return f
+	}
+	return DefaultLoadFactor
+}
+
+// ring syncs the ring of the service with its nodes, removing it once
+// the service has no nodes left. The lock must be held.
+func (c *consistentSelector) ring(service string, services []*registry.Service) {
+	var nodes []*registry.Node
+	for _, s := range services {
+		nodes = append(nodes, s.Nodes...)
+	}
+
+	if len(nodes) == 0 {
+		delete(c.rings, service)
+		return
+	}
+
+	r, ok := c.rings[service]
+	if !ok {
+		r = newRing(c.replicas())
+		c.rings[service] = r
+	}
+
+	r.sync(nodes)
+}
+
+// next returns the first node clockwise of the hash which hasn't been tried and
+// is within the bound on its load. Once every node has been tried it starts again.
+func (c *consistentSelector) next(service string, h uint32, allowed map[string]bool) selector.Next {
+	tried := make(map[string]bool)
+
+	return func() (*registry.Node, error) {
+		c.Lock()
+		defer c.Unlock()
+
+		r, ok := c.rings[service]
+		if !ok {
+			return nil, selector.ErrNoneAvailable
+		}
+
+		if len(tried) >= len(allowed) {
+			tried = make(map[string]bool)
+		}
+
+		// the bound is the load factor times the average load, including this request
+		var total, count int
+		for id := range allowed {
+			if _, ok := r.nodes[id]; ok {
+				total += c.loads[service][id]
+				count++
+			}
+		}
+
+		if count == 0 {
+			return nil, selector.ErrNoneAvailable
+		}
+
+		bound := int(math.Ceil(c.loadFactor() * float64(total+1) / float64(count)))
+
+		node := r.get(h, func(n *registry.Node) bool {
+			return allowed[n.Id] && !tried[n.Id] && c.loads[service][n.Id] < bound
+		})
+
+		// the nodes within the bound have been tried
+		if node == nil {
+			node = r.get(h, func(n *registry.Node) bool {
+				return allowed[n.Id] && !tried[n.Id]
+			})
+		}
+
+		if node == nil {
+			return nil, selector.ErrNoneAvailable
+		}
+
+		tried[node.Id] = true
+		c.start(service, node.Id)
+
+		return node, nil
+	}
+}
+
+// start counts a request in flight to the node, the lock must be held
+func (c *consistentSelector) start(service, id string) {
+	loads, ok := c.loads[service]
+	if !ok {
+		loads = make(map[string]int)
+		c.loads[service] = loads
+	}
+	loads[id]++
+}
+
+// strategy counts the requests to the nodes picked by the strategy
+// of a call, so they're ended by Mark like any other
+func (c *consistentSelector) strategy(service string, next selector.Next) selector.Next {
+	return func() (*registry.Node, error) {
+		node, err := next()
+		if err != nil {
+			return nil, err
+		}
+
+		c.Lock()
+		c.start(service, node.Id)
+		c.Unlock()
+
+		return node, nil
+	}
+}
+
+func (c *consistentSelector) Init(opts ...selector.Option) error {
+	c.Lock()
+	defer c.Unlock()
+
+	r := c.so.Registry
+	for _, o := range opts {
+		o(&c.so)
+	}
+
+	// cache the new registry
+	if c.so.Registry != r {
+		c.rc.Stop()
+		c.rc = cache.New(c.so.Registry)
+	}
+
+	return nil
+}
+
+func (c *consistentSelector) Options() selector.Options {
+	c.Lock()
+	defer c.Unlock()
+	return c.so
+}
+
+func (c *consistentSelector) Select(service string, opts ...selector.SelectOption) (selector.Next, error) {
+	sopts := selector.SelectOptions{
+		Context: context.TODO(),
+	}
+
+	for _, opt := range opts {
+		opt(&sopts)
+	}
+
+	c.Lock()
+	rc := c.rc
+	c.Unlock()
+
+	// get the service
+	services, err := rc.GetService(service)
+	if err == registry.ErrNotFound {
+		c.Lock()
+		c.ring(service, nil)
+		c.Unlock()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// sync the ring with every node so it's stable whatever the filters
+	c.Lock()
+	c.ring(service, services)
+	c.Unlock()
+
+	// apply the filters
+	for _, filter := range sopts.Filters {
+		services = filter(services)
+	}
+
+	// if there's nothing left, return
+	if len(services) == 0 {
+		return nil, selector.ErrNotFound
+	}
+
+	// a strategy for the call takes precedence
+	if sopts.Strategy != nil {
+		return c.strategy(service, sopts.Strategy(services)), nil
+	}
+
+	allowed := make(map[string]bool)
+	for _, s := range services {
+		for _, node := range s.Nodes {
+			allowed[node.Id] = true
+		}
+	}
+
+	// any nodes left?
+	if len(allowed) == 0 {
+		return nil, selector.ErrNoneAvailable
+	}
+
+	h := rand.Uint32()
+	if key, ok := sopts.Context.Value(keyKey{}).(string); ok && len(key) > 0 {
+		h = hash(key)
+	}
+
+	return c.next(service, h, allowed), nil
+}
+
+// Mark ends a request to the node, reducing its load. Only
+// requests to nodes picked by the selector are counted.
+func (c *consistentSelector) Mark(service string, node *registry.Node, err error) {
+	c.Lock()
+	defer c.Unlock()
+
+	loads, ok := c.loads[service]
+	if !ok || loads[node.Id] == 0 {
+		return
+	}
+
+	loads[node.Id]--
+	if loads[node.Id] > 0 {
+		return
+	}
+
+	delete(loads, node.Id)
+	if len(loads) == 0 {
+		delete(c.loads, service)
+	}
+}
+
+func (c *consistentSelector) Reset(service string) {
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.loads, service)
+}
+
+func (c *consistentSelector) Close() error {
+	c.Lock()
+	c.rc.Stop()
+	c.Unlock()
+	return nil
+}
+
+func (c *consistentSelector) String() string {
+	return "consistent"
+}
+
+func NewSelector(opts ...selector.Option) selector.Selector {
+	sopts := selector.Options{
+		Context:  context.TODO(),
+		Registry: registry.DefaultRegistry,
+	}
+
+	for _, opt := range opts {
+		opt(&sopts)
+	}
+
+	return &consistentSelector{
+		so:    sopts,
+		rc:    cache.New(sopts.Registry),
+		rings: make(map[string]*ring),
+		loads: make(map[string]map[string]int),
+	}
+}
@@ -0,0 +1,253 @@
+package consistent
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+	"github.com/micro/go-plugins/registry/memory"
+)
+
+func testNodes(n int) []*registry.Node {
+	var nodes []*registry.Node
+	for i := 0; i < n; i++ {
+		nodes = append(nodes, &registry.Node{
+			Id:      fmt.Sprintf("foo-%d", i),
+			Address: "localhost",
+			Port:    8080 + i,
+		})
+	}
+	return nodes
+}
+
+func testSelector(n int, opts ...selector.Option) selector.Selector {
+	r := memory.NewRegistry()
+	r.Register(&registry.Service{
+		Name:    "foo",
+		Version: "1.0.0",
+		Nodes:   testNodes(n),
+	})
+	return NewSelector(append(opts, selector.Registry(r))...)
+}
+
+func pick(t *testing.T, s selector.Selector, key string) *registry.Node {
+	next, err := s.Select("foo", Key(key))
+	if err != nil {
+		t.Fatalf("Unexpected error calling select: %v", err)
+	}
+	node, err := next()
+	if err != nil {
+		t.Fatalf("Expected node, got err: %v", err)
+	}
+	return node
+}
+
+func TestConsistent(t *testing.T) {
+	s := testSelector(5)
+	defer s.Close()
+
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		node := pick(t, s, key)
+		s.Mark("foo", node, nil)
+
+		// the key goes to the same node
+		for j := 0; j < 10; j++ {
+			n := pick(t, s, key)
+			s.Mark("foo", n, nil)
+			if n.Id != node.Id {
+				t.Fatalf("Expected %s to go to %s, got %s", key, node.Id, n.Id)
+			}
+		}
+	}
+}
+
+func TestRingSync(t *testing.T) {
+	r := newRing(DefaultReplicas)
+	nodes := testNodes(5)
+	r.sync(nodes[:4])
+
+	all := func(*registry.Node) bool { return true }
+
+	before := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		before[key] = r.get(hash(key), all).Id
+	}
+
+	// only keys moving to the added node change
+	r.sync(nodes)
+
+	var moved int
+	for key, id := range before {
+		n := r.get(hash(key), all)
+		if n.Id == id {
+			continue
+		}
+		if n.Id != "foo-4" {
+			t.Fatalf("Expected %s to stay on %s or move to foo-4, got %s", key, id, n.Id)
+		}
+		moved++
+	}
+
+	if moved == 0 || moved > 350 {
+		t.Fatalf("Expected about a fifth of keys to move, %d of 1000 moved", moved)
+	}
+
+	// and removing it moves them back
+	r.sync(nodes[:4])
+
+	if len(r.points) != 4*DefaultReplicas {
+		t.Fatalf("Expected %d points, got %d", 4*DefaultReplicas, len(r.points))
+	}
+
+	for key, id := range before {
+		if n := r.get(hash(key), all); n.Id != id {
+			t.Fatalf("Expected %s on %s, got %s", key, id, n.Id)
+		}
+	}
+}
+
+func TestRingRemoved(t *testing.T) {
+	r := memory.NewRegistry()
+	service := &registry.Service{
+		Name:    "foo",
+		Version: "1.0.0",
+		Nodes:   testNodes(3),
+	}
+	r.Register(service)
+
+	s := NewSelector(selector.Registry(r))
+	defer s.Close()
+
+	// cache the service and wait for the cache to watch
+	pick(t, s, "key")
+	time.Sleep(time.Millisecond * 50)
+
+	r.Deregister(service)
+	time.Sleep(time.Millisecond * 50)
+
+	if _, err := s.Select("foo", Key("key")); err == nil {
+		t.Fatal("Expected foo not to be found")
+	}
+
+	c := s.(*consistentSelector)
+	c.Lock()
+	rings := len(c.rings)
+	c.Unlock()
+
+	if rings != 0 {
+		t.Fatalf("Expected the ring of foo to be removed, got %d rings", rings)
+	}
+}
+
+func TestBoundedLoad(t *testing.T) {
+	s := testSelector(3, LoadFactor(1.25))
+	defer s.Close()
+
+	// a hot key with requests in flight spills over to other nodes
+	counts := make(map[string]int)
+	for i := 0; i < 30; i++ {
+		counts[pick(t, s, "hot").Id]++
+	}
+
+	if len(counts) != 3 {
+		t.Fatalf("Expected the hot key to spill over to every node, got %v", counts)
+	}
+
+	for id, n := range counts {
+		if n > 13 {
+			t.Fatalf("Expected at most 13 requests in flight to %s, got %d", id, n)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	s := testSelector(3)
+	defer s.Close()
+
+	next, err := s.Select("foo", Key("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// retries go to the other nodes
+	seen := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		node, err := next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Mark("foo", node, nil)
+		seen[node.Id] = true
+	}
+
+	if len(seen) != 3 {
+		t.Fatalf("Expected every node to be tried, got %v", seen)
+	}
+}
+
+// load returns the requests in flight to the node of the service
+func load(s selector.Selector, service, id string) int {
+	c := s.(*consistentSelector)
+	c.Lock()
+	defer c.Unlock()
+	return c.loads[service][id]
+}
+
+func TestLoadPerService(t *testing.T) {
+	s := testSelector(3)
+	defer s.Close()
+
+	node := pick(t, s, "key")
+
+	if n := load(s, "foo", node.Id); n != 1 {
+		t.Fatalf("Expected 1 request in flight to %s, got %d", node.Id, n)
+	}
+
+	// another service with the same node id doesn't end it
+	s.Mark("bar", node, nil)
+
+	if n := load(s, "foo", node.Id); n != 1 {
+		t.Fatalf("Expected 1 request in flight to %s, got %d", node.Id, n)
+	}
+
+	s.Mark("foo", node, nil)
+	s.Mark("foo", node, nil)
+
+	if n := load(s, "foo", node.Id); n != 0 {
+		t.Fatalf("Expected no requests in flight to %s, got %d", node.Id, n)
+	}
+}
+
+func TestStrategyLoad(t *testing.T) {
+	s := testSelector(3)
+	defer s.Close()
+
+	next, err := s.Select("foo", selector.WithStrategy(func(services []*registry.Service) selector.Next {
+		return func() (*registry.Node, error) {
+			return services[0].Nodes[0], nil
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// requests picked by a strategy are counted and ended by mark
+	if n := load(s, "foo", node.Id); n != 1 {
+		t.Fatalf("Expected 1 request in flight to %s, got %d", node.Id, n)
+	}
+
+	s.Mark("foo", node, nil)
+
+	if n := load(s, "foo", node.Id); n != 0 {
+		t.Fatalf("Expected no requests in flight to %s, got %d", node.Id, n)
+	}
+}
@@ -0,0 +1,15 @@
+// Package consistent is a consistent hashing selector with bounded loads.
+package consistent
+
+/*
+   A consistent hashing selector. The nodes of a service are placed on a hash ring many times
+   as virtual nodes and a request is sent to the first node clockwise of the hash of its key, so
+   the same key goes to the same node and only the keys of a node which comes or goes move.
+
+   Loads are bounded. A node is skipped while it has more than the load factor times the average
+   number of requests in flight, so a hot key spills over to the next nodes on the ring rather
+   than overloading one node. Requests in flight are counted from selection until Mark.
+
+   The key is set per call with the Key select option, or taken from the request metadata
+   by the client wrapper. Requests without a key are sent to a random point on the ring.
+*/
@@ -0,0 +1,41 @@
+package consistent
+
+import (
+	"github.com/micro/go-micro/selector"
+	"golang.org/x/net/context"
+)
+
+type keyKey struct{}
+type replicasKey struct{}
+type loadFactorKey struct{}
+
+// Key sets the hash key of the call
+func Key(k string) selector.SelectOption {
+	return func(o *selector.SelectOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, keyKey{}, k)
+	}
+}
+
+// Replicas sets the number of virtual nodes of each node on the ring
+func Replicas(n int) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, replicasKey{}, n)
+	}
+}
+
+// LoadFactor bounds the requests in flight to a node to the
+// factor times the average, it should be greater than 1
+func LoadFactor(c float64) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, loadFactorKey{}, c)
+	}
+}
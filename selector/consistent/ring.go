@@ -0,0 +1,113 @@
+package consistent
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+
+	"github.com/micro/go-micro/registry"
+)
+
+// point is a virtual node on the ring
+type point struct {
+	hash uint32
+	id   string
+}
+
+// ring is the hash ring of the nodes of a service
+type ring struct {
+	replicas int
+	// points sorted by hash
+	points []point
+	nodes  map[string]*registry.Node
+}
+
+func newRing(replicas int) *ring {
+	return &ring{
+		replicas: replicas,
+		nodes:    make(map[string]*registry.Node),
+	}
+}
+
+func hash(key string) uint32 {
+	return crc32.ChecksumIEEE([]byte(key))
+}
+
+// sync adds and removes the points of the nodes which have come
+// and gone rather than rebuilding the ring
+func (r *ring) sync(nodes []*registry.Node) {
+	current := make(map[string]*registry.Node, len(nodes))
+	for _, node := range nodes {
+		current[node.Id] = node
+	}
+
+	removed := false
+	for id := range r.nodes {
+		if _, ok := current[id]; !ok {
+			delete(r.nodes, id)
+			removed = true
+		}
+	}
+
+	if removed {
+		points := r.points[:0]
+		for _, p := range r.points {
+			if _, ok := r.nodes[p.id]; ok {
+				points = append(points, p)
+			}
+		}
+		r.points = points
+	}
+
+	var added []point
+	for id, node := range current {
+		if _, ok := r.nodes[id]; !ok {
+			for i := 0; i < r.replicas; i++ {
+				added = append(added, point{hash(id + "-" + strconv.Itoa(i)), id})
+			}
+		}
+		// keep the latest node
+		r.nodes[id] = node
+	}
+
+	if len(added) > 0 {
+		sort.Slice(added, func(i, j int) bool { return added[i].hash < added[j].hash })
+		r.points = merge(r.points, added)
+	}
+}
+
+// merge merges two sorted lists of points
+func merge(a, b []point) []point {
+	points := make([]point, 0, len(a)+len(b))
+
+	for len(a) > 0 && len(b) > 0 {
+		if a[0].hash <= b[0].hash {
+			points = append(points, a[0])
+			a = a[1:]
+		} else {
+			points = append(points, b[0])
+			b = b[1:]
+		}
+	}
+
+	points = append(points, a...)
+	return append(points, b...)
+}
+
+// get returns the first node clockwise of the hash which the filter accepts
+func (r *ring) get(h uint32, accept func(*registry.Node) bool) *registry.Node {
+	if len(r.points) == 0 {
+		return nil
+	}
+
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+
+	for n := 0; n < len(r.points); n++ {
+		p := r.points[(i+n)%len(r.points)]
+		if node := r.nodes[p.id]; accept(node) {
+			return node
+		}
+	}
+
+	return nil
+}
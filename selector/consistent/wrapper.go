@@ -0,0 +1,42 @@
+package consistent
+
+import (
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/metadata"
+
+	"golang.org/x/net/context"
+)
+
+type consistentWrapper struct {
+	key string
+	client.Client
+}
+
+func (c *consistentWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	// get headers
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		return c.Client.Call(ctx, req, rsp, opts...)
+	}
+
+	// noop on nil value
+	val := md[c.key]
+	if len(val) == 0 {
+		return c.Client.Call(ctx, req, rsp, opts...)
+	}
+
+	nOpts := append(opts, client.WithSelectOption(Key(val)))
+
+	return c.Client.Call(ctx, req, rsp, nOpts...)
+}
+
+// NewClientWrapper is a wrapper which sets the hash key of
+// calls to the value of the metadata key for the selector
+func NewClientWrapper(key string) client.Wrapper {
+	return func(c client.Client) client.Client {
+		return &consistentWrapper{
+			key:    key,
+			Client: c,
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package consistent
+
+import (
+	"testing"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/selector"
+
+	"golang.org/x/net/context"
+)
+
+// testClient records the hash key of the last call
+type testClient struct {
+	client.Client
+	key string
+}
+
+func (t *testClient) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	var callOpts client.CallOptions
+	for _, o := range opts {
+		o(&callOpts)
+	}
+
+	var sopts selector.SelectOptions
+	for _, o := range callOpts.SelectOptions {
+		o(&sopts)
+	}
+
+	t.key = ""
+	if sopts.Context != nil {
+		t.key, _ = sopts.Context.Value(keyKey{}).(string)
+	}
+	return nil
+}
+
+func TestWrapper(t *testing.T) {
+	c := &testClient{}
+	w := NewClientWrapper("X-User-Id")(c)
+
+	testData := []struct {
+		md  metadata.Metadata
+		key string
+	}{
+		{nil, ""},
+		{metadata.Metadata{"Foo": "bar"}, ""},
+		{metadata.Metadata{"X-User-Id": ""}, ""},
+		{metadata.Metadata{"X-User-Id": "user-1"}, "user-1"},
+	}
+
+	for _, d := range testData {
+		ctx := context.Background()
+		if d.md != nil {
+			ctx = metadata.NewContext(ctx, d.md)
+		}
+
+		if err := w.Call(ctx, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if c.key != d.key {
+			t.Fatalf("Expected key %q for %v, got %q", d.key, d.md, c.key)
+		}
+	}
+}
@@ -7,4 +7,8 @@ package label
    nodes are still returned. The priority based label selector is useful for such things
    as rudimentary AZ based routing where requests made to other services should remain
    in the same AZ.
+
+   Labels can also be match expressions like those of Kubernetes, which either order
+   the nodes like labels or are required, in which case nodes which don't match every
+   required expression are never returned. Expressions can be set per call.
 */
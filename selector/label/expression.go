@@ -0,0 +1,73 @@
+package label
+
+import (
+	"github.com/blang/semver"
+	"github.com/micro/go-micro/registry"
+)
+
+// Operator of an expression
+type Operator string
+
+const (
+	// In matches nodes with the key set to one of the values
+	In Operator = "In"
+	// NotIn matches nodes without the key or with it set to none of the values
+	NotIn Operator = "NotIn"
+	// Exists matches nodes with the key
+	Exists Operator = "Exists"
+	// DoesNotExist matches nodes without the key
+	DoesNotExist Operator = "DoesNotExist"
+	// SemVer matches nodes whose version is in one of the ranges e.g ">=1.0.0 <2.0.0".
+	// The version is the version of the service or, if the key is set, its metadata value.
+	SemVer Operator = "SemVer"
+)
+
+// Expression matches the metadata or version of nodes
+type Expression struct {
+	Key      string
+	Operator Operator
+	Values   []string
+}
+
+func contains(values []string, v string) bool {
+	for _, val := range values {
+		if val == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches returns true if the node of the service version matches the expression
+func (e Expression) Matches(version string, node *registry.Node) bool {
+	val, ok := node.Metadata[e.Key]
+
+	switch e.Operator {
+	case In:
+		return ok && contains(e.Values, val)
+	case NotIn:
+		return !ok || !contains(e.Values, val)
+	case Exists:
+		return ok
+	case DoesNotExist:
+		return !ok
+	case SemVer:
+		if len(e.Key) == 0 {
+			val = version
+		}
+
+		v, err := semver.ParseTolerant(val)
+		if err != nil {
+			return false
+		}
+
+		for _, r := range e.Values {
+			rng, err := semver.ParseRange(r)
+			if err == nil && rng(v) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -18,41 +18,72 @@ func init() {
 	cmd.DefaultSelectors["label"] = NewSelector
 }
 
-func prioritise(nodes []*registry.Node, labels []label) []*registry.Node {
+// versioned is a node and the version of its service
+type versioned struct {
+	node    *registry.Node
+	version string
+}
+
+// expressions of the labels
+func labelExpressions(labels []label) []Expression {
+	var exprs []Expression
+	for _, l := range labels {
+		exprs = append(exprs, Expression{Key: l.key, Operator: In, Values: []string{l.val}})
+	}
+	return exprs
+}
+
+// require returns the nodes which match every expression
+func require(nodes []versioned, exprs []Expression) []versioned {
+	var rnodes []versioned
+
+	for _, n := range nodes {
+		matched := true
+		for _, expr := range exprs {
+			if !expr.Matches(n.version, n.node) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			rnodes = append(rnodes, n)
+		}
+	}
+
+	return rnodes
+}
+
+// prioritise orders the nodes by the first expression they match followed by the leftovers
+func prioritise(nodes []versioned, exprs []Expression) []*registry.Node {
 	var lnodes []*registry.Node
 	marked := make(map[string]bool)
 
-	for _, label := range labels {
-		for _, node := range nodes {
+	for _, expr := range exprs {
+		for _, n := range nodes {
 			// already used
-			if _, ok := marked[node.Id]; ok {
+			if _, ok := marked[n.node.Id]; ok {
 				continue
 			}
 
-			// nil metadata?
-			if node.Metadata == nil {
-				continue
-			}
-
-			// matching label?
-			if val, ok := node.Metadata[label.key]; !ok || label.val != val {
+			// matching expression?
+			if !expr.Matches(n.version, n.node) {
 				continue
 			}
 
 			// matched! mark it
-			marked[node.Id] = true
+			marked[n.node.Id] = true
 
 			// append to nodes
-			lnodes = append(lnodes, node)
+			lnodes = append(lnodes, n.node)
 		}
 	}
 
 	// grab the leftovers
-	for _, node := range nodes {
-		if _, ok := marked[node.Id]; ok {
+	for _, n := range nodes {
+		if _, ok := marked[n.node.Id]; ok {
 			continue
 		}
-		lnodes = append(lnodes, node)
+		lnodes = append(lnodes, n.node)
 	}
 
 	return lnodes
@@ -107,28 +138,37 @@ func (r *labelSelector) Select(service string, opts ...selector.SelectOption) (s
 		return nil, selector.ErrNotFound
 	}
 
-	var nodes []*registry.Node
+	var nodes []versioned
 
 	// flatten node list
 	for _, service := range services {
 		for _, node := range service.Nodes {
-			nodes = append(nodes, node)
+			nodes = append(nodes, versioned{node, service.Version})
 		}
 	}
 
+	// drop the nodes which don't match the required expressions
+	var required []Expression
+	required = append(required, expressions(r.so.Context, requireKey{})...)
+	required = append(required, expressions(sopts.Context, requireKey{})...)
+	nodes = require(nodes, required)
+
 	// any nodes left?
 	if len(nodes) == 0 {
-		return nil, selector.ErrNotFound
+		return nil, selector.ErrNoneAvailable
 	}
 
-	// now prioritise the list based on labels
+	// now prioritise the list based on labels and preferred expressions
 	// oh god the O(n)^2 cruft or well not really
 	// more like O(m*n) or something like that
+	var prefer []Expression
 	if labels, ok := r.so.Context.Value(labelKey{}).([]label); ok {
-		nodes = prioritise(nodes, labels)
+		prefer = labelExpressions(labels)
 	}
+	prefer = append(prefer, expressions(r.so.Context, preferKey{})...)
+	prefer = append(prefer, expressions(sopts.Context, preferKey{})...)
 
-	return next(nodes), nil
+	return next(prioritise(nodes, prefer)), nil
 }
 
 func (r *labelSelector) Mark(service string, node *registry.Node, err error) {
@@ -8,6 +8,14 @@ import (
 	"github.com/micro/go-micro/selector"
 )
 
+func unversioned(nodes []*registry.Node) []versioned {
+	var vnodes []versioned
+	for _, node := range nodes {
+		vnodes = append(vnodes, versioned{node: node})
+	}
+	return vnodes
+}
+
 func TestPrioritiseFunc(t *testing.T) {
 	nodes := []*registry.Node{
 		&registry.Node{
@@ -37,7 +45,7 @@ func TestPrioritiseFunc(t *testing.T) {
 		label{"key2", "val2"},
 	}
 
-	lnodes := prioritise(nodes, labels)
+	lnodes := prioritise(unversioned(nodes), labelExpressions(labels))
 	t.Log("Prioritised node list #1")
 	for _, node := range lnodes {
 		t.Logf("Node %+v", node)
@@ -52,7 +60,7 @@ func TestPrioritiseFunc(t *testing.T) {
 		label{"key2", "val2"},
 	}
 
-	lnodes = prioritise(nodes, labels)
+	lnodes = prioritise(unversioned(nodes), labelExpressions(labels))
 	t.Log("Prioritised node list #2")
 	for _, node := range lnodes {
 		t.Logf("Node %+v", node)
@@ -134,3 +142,126 @@ func TestLabelSelector(t *testing.T) {
 
 	t.Logf("Label Select Counts %v", counts)
 }
+
+func TestExpressions(t *testing.T) {
+	node := &registry.Node{
+		Id: "1",
+		Metadata: map[string]string{
+			"env":     "prod",
+			"version": "2.1.0",
+		},
+	}
+
+	data := []struct {
+		expr    Expression
+		matches bool
+	}{
+		{Expression{Key: "env", Operator: In, Values: []string{"dev", "prod"}}, true},
+		{Expression{Key: "env", Operator: In, Values: []string{"dev"}}, false},
+		{Expression{Key: "env", Operator: NotIn, Values: []string{"dev"}}, true},
+		{Expression{Key: "zone", Operator: NotIn, Values: []string{"a"}}, true},
+		{Expression{Key: "env", Operator: Exists}, true},
+		{Expression{Key: "zone", Operator: Exists}, false},
+		{Expression{Key: "zone", Operator: DoesNotExist}, true},
+		{Expression{Operator: SemVer, Values: []string{">=1.0.0 <2.0.0"}}, true},
+		{Expression{Operator: SemVer, Values: []string{">=2.0.0"}}, false},
+		{Expression{Operator: SemVer, Values: []string{"<1.0.0", ">=1.2.0"}}, true},
+		{Expression{Key: "version", Operator: SemVer, Values: []string{">=2.0.0"}}, true},
+	}
+
+	for _, d := range data {
+		if m := d.expr.Matches("1.2.3", node); m != d.matches {
+			t.Errorf("Expected %+v to match %v, got %v", d.expr, d.matches, m)
+		}
+	}
+}
+
+func TestRequire(t *testing.T) {
+	r := mock.NewRegistry()
+	r.Register(&registry.Service{
+		Name:    "bar",
+		Version: "1.0.0",
+		Nodes: []*registry.Node{
+			&registry.Node{
+				Id:       "1",
+				Metadata: map[string]string{"env": "prod"},
+			},
+			&registry.Node{
+				Id:       "2",
+				Metadata: map[string]string{"env": "dev"},
+			},
+		},
+	})
+	r.Register(&registry.Service{
+		Name:    "bar",
+		Version: "2.0.0",
+		Nodes: []*registry.Node{
+			&registry.Node{
+				Id:       "3",
+				Metadata: map[string]string{"env": "prod"},
+			},
+		},
+	})
+
+	ls := NewSelector(
+		selector.Registry(r),
+		Require(Expression{Key: "env", Operator: In, Values: []string{"prod"}}),
+	)
+
+	// only prod nodes are returned
+	next, err := ls.Select("bar")
+	if err != nil {
+		t.Fatalf("Unexpected error calling ls select: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		node, err := next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Id == "2" {
+			t.Fatal("Expected dev node to be filtered")
+		}
+	}
+
+	// per call expressions are added to the selector's
+	next, err = ls.Select("bar", WithRequire(Expression{Operator: SemVer, Values: []string{">=2.0.0 <3.0.0"}}))
+	if err != nil {
+		t.Fatalf("Unexpected error calling ls select: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		node, err := next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Id != "3" {
+			t.Fatalf("Expected node 3, got %s", node.Id)
+		}
+	}
+
+	_, err = ls.Select("bar", WithRequire(Expression{Key: "env", Operator: DoesNotExist}))
+	if err != selector.ErrNoneAvailable {
+		t.Fatalf("Expected %v, got %v", selector.ErrNoneAvailable, err)
+	}
+
+	// preferred nodes come first
+	next, err = ls.Select("bar", WithPrefer(Expression{Operator: SemVer, Values: []string{">=2.0.0"}}))
+	if err != nil {
+		t.Fatalf("Unexpected error calling ls select: %v", err)
+	}
+
+	if node, _ := next(); node.Id != "3" {
+		t.Fatalf("Expected node 3 first, got %s", node.Id)
+	}
+
+	next, err = ls.Select("bar", WithLabel("env", "dev"))
+	if err != nil {
+		t.Fatalf("Unexpected error calling ls select: %v", err)
+	}
+
+	// dev is required out so the label has no effect
+	if node, _ := next(); node.Id == "2" {
+		t.Fatal("Expected dev node to be filtered")
+	}
+}
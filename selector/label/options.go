@@ -6,6 +6,8 @@ import (
 )
 
 type labelKey struct{}
+type preferKey struct{}
+type requireKey struct{}
 
 type label struct {
 	key string
@@ -23,3 +25,55 @@ func Label(k, v string) selector.Option {
 		o.Context = context.WithValue(o.Context, labelKey{}, l)
 	}
 }
+
+// Prefer adds expressions to the priority list. Nodes are ordered
+// by the first expression they match followed by the leftovers.
+func Prefer(exprs ...Expression) selector.Option {
+	return func(o *selector.Options) {
+		o.Context = withExpressions(o.Context, preferKey{}, exprs)
+	}
+}
+
+// Require adds expressions which every node must match
+func Require(exprs ...Expression) selector.Option {
+	return func(o *selector.Options) {
+		o.Context = withExpressions(o.Context, requireKey{}, exprs)
+	}
+}
+
+// WithLabel adds a label to the priority list of the call
+func WithLabel(k, v string) selector.SelectOption {
+	return WithPrefer(Expression{Key: k, Operator: In, Values: []string{v}})
+}
+
+// WithPrefer adds expressions to the priority list of the call
+func WithPrefer(exprs ...Expression) selector.SelectOption {
+	return func(o *selector.SelectOptions) {
+		o.Context = withExpressions(o.Context, preferKey{}, exprs)
+	}
+}
+
+// WithRequire adds expressions which every node must match for the call
+func WithRequire(exprs ...Expression) selector.SelectOption {
+	return func(o *selector.SelectOptions) {
+		o.Context = withExpressions(o.Context, requireKey{}, exprs)
+	}
+}
+
+func withExpressions(ctx context.Context, key interface{}, exprs []Expression) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	e, _ := ctx.Value(key).([]Expression)
+	// copy so contexts don't share the backing array
+	e = append(append([]Expression{}, e...), exprs...)
+	return context.WithValue(ctx, key, e)
+}
+
+func expressions(ctx context.Context, key interface{}) []Expression {
+	if ctx == nil {
+		return nil
+	}
+	e, _ := ctx.Value(key).([]Expression)
+	return e
+}
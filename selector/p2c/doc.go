@@ -0,0 +1,14 @@
+// Package p2c is a latency aware power of two choices selector.
+package p2c
+
+/*
+   A power of two choices selector. Two nodes are picked at random and the request is sent to
+   the one with the lower cost, the moving average of its latency times its requests in flight.
+   Picking the better of two random nodes sends less traffic to slow or busy nodes without
+   herding every client onto the single best one.
+
+   The latency of a request is the time from selecting the node until Mark, which the client
+   calls after every request. Requests which fail count as taking at least the penalty latency.
+   Mark can't tell concurrent requests to a node apart, so it ends the oldest one. The latency
+   is then approximate while requests overlap, but the average evens out.
+*/
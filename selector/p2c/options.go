@@ -0,0 +1,31 @@
+package p2c
+
+import (
+	"time"
+
+	"github.com/micro/go-micro/selector"
+	"golang.org/x/net/context"
+)
+
+type smoothingKey struct{}
+type penaltyKey struct{}
+
+// Smoothing sets the weight from 0 to 1 of the latest latency in the moving average
+func Smoothing(alpha float64) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, smoothingKey{}, alpha)
+	}
+}
+
+// Penalty sets the least latency counted for failed requests
+func Penalty(d time.Duration) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, penaltyKey{}, d)
+	}
+}
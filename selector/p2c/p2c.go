@@ -0,0 +1,252 @@
+package p2c
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/cmd"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+
+	"golang.org/x/net/context"
+)
+
+type p2cSelector struct {
+	so selector.Options
+	// now is replaced in tests
+	now func() time.Time
+
+	sync.Mutex
+	stats map[string]*stats
+}
+
+// stats of the requests to a node
+type stats struct {
+	service string
+	// moving average of the latency in nanoseconds
+	latency float64
+	sampled bool
+	// start times of the requests in flight
+	starts []time.Time
+}
+
+var (
+	// DefaultSmoothing is the weight of the latest latency in the moving average
+	DefaultSmoothing = 0.3
+	// DefaultPenalty is the least latency counted for failed requests
+	DefaultPenalty = time.Second
+)
+
+func init() {
+	rand.Seed(time.Now().UnixNano())
+	cmd.DefaultSelectors["p2c"] = NewSelector
+}
+
+func (p *p2cSelector) smoothing() float64 {
+	if a, ok := p.so.Context.Value(smoothingKey{}).(float64); ok && a > 0 && a <= 1 {
+		return a
+	}
+	return DefaultSmoothing
+}
+
+func (p *p2cSelector) penalty() time.Duration {
+	if d, ok := p.so.Context.Value(penaltyKey{}).(time.Duration); ok {
+		return d
+	}
+	return DefaultPenalty
+}
+
+// get returns the stats of the node, the lock must be held
+func (p *p2cSelector) get(service, id string) *stats {
+	s, ok := p.stats[id]
+	if !ok {
+		s = &stats{service: service}
+		p.stats[id] = s
+	}
+	return s
+}
+
+// cost is the latency times the requests in flight. A node without
+// any latency yet is given the latency of the other node.
+func cost(s, other *stats) float64 {
+	latency := s.latency
+	if !s.sampled {
+		latency = other.latency
+	}
+	return (latency + 1) * float64(len(s.starts)+1)
+}
+
+// prune drops the stats of the nodes of the service which are no longer
+// registered, keeping those with requests in flight until they're marked
+func (p *p2cSelector) prune(service string, services []*registry.Service) {
+	seen := make(map[string]bool)
+	for _, s := range services {
+		for _, node := range s.Nodes {
+			seen[node.Id] = true
+		}
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	for id, s := range p.stats {
+		if s.service == service && !seen[id] && len(s.starts) == 0 {
+			delete(p.stats, id)
+		}
+	}
+}
+
+// next picks the cheaper of two random nodes
+func (p *p2cSelector) next(service string, nodes []*registry.Node) selector.Next {
+	return func() (*registry.Node, error) {
+		p.Lock()
+		defer p.Unlock()
+
+		node := nodes[0]
+
+		if len(nodes) > 1 {
+			i := rand.Intn(len(nodes))
+			j := rand.Intn(len(nodes) - 1)
+			if j >= i {
+				j++
+			}
+
+			a := p.get(service, nodes[i].Id)
+			b := p.get(service, nodes[j].Id)
+
+			node = nodes[i]
+			if cost(b, a) < cost(a, b) {
+				node = nodes[j]
+			}
+		}
+
+		s := p.get(service, node.Id)
+		s.starts = append(s.starts, p.now())
+
+		return node, nil
+	}
+}
+
+func (p *p2cSelector) Init(opts ...selector.Option) error {
+	for _, o := range opts {
+		o(&p.so)
+	}
+	return nil
+}
+
+func (p *p2cSelector) Options() selector.Options {
+	return p.so
+}
+
+func (p *p2cSelector) Select(service string, opts ...selector.SelectOption) (selector.Next, error) {
+	var sopts selector.SelectOptions
+	for _, opt := range opts {
+		opt(&sopts)
+	}
+
+	// get the service
+	services, err := p.so.Registry.GetService(service)
+	if err != nil {
+		return nil, err
+	}
+
+	// forget the nodes which have gone, whatever the filters
+	p.prune(service, services)
+
+	// apply the filters
+	for _, filter := range sopts.Filters {
+		services = filter(services)
+	}
+
+	// if there's nothing left, return
+	if len(services) == 0 {
+		return nil, selector.ErrNotFound
+	}
+
+	// a strategy for the call takes precedence
+	if sopts.Strategy != nil {
+		return sopts.Strategy(services), nil
+	}
+
+	var nodes []*registry.Node
+
+	// flatten node list
+	for _, service := range services {
+		for _, node := range service.Nodes {
+			nodes = append(nodes, node)
+		}
+	}
+
+	// any nodes left?
+	if len(nodes) == 0 {
+		return nil, selector.ErrNoneAvailable
+	}
+
+	return p.next(service, nodes), nil
+}
+
+// Mark ends the oldest request in flight to the node and records its latency.
+// Mark isn't told which request ended so with concurrent requests the latency
+// is approximate, the oldest start is paired with whichever request ends first.
+func (p *p2cSelector) Mark(service string, node *registry.Node, err error) {
+	p.Lock()
+	defer p.Unlock()
+
+	s, ok := p.stats[node.Id]
+	if !ok || len(s.starts) == 0 {
+		return
+	}
+
+	latency := p.now().Sub(s.starts[0])
+	s.starts = s.starts[1:]
+
+	if err != nil && latency < p.penalty() {
+		latency = p.penalty()
+	}
+
+	if !s.sampled {
+		s.latency = float64(latency)
+		s.sampled = true
+		return
+	}
+
+	a := p.smoothing()
+	s.latency = a*float64(latency) + (1-a)*s.latency
+}
+
+func (p *p2cSelector) Reset(service string) {
+	p.Lock()
+	defer p.Unlock()
+
+	for id, s := range p.stats {
+		if s.service == service {
+			delete(p.stats, id)
+		}
+	}
+}
+
+func (p *p2cSelector) Close() error {
+	return nil
+}
+
+func (p *p2cSelector) String() string {
+	return "p2c"
+}
+
+func NewSelector(opts ...selector.Option) selector.Selector {
+	sopts := selector.Options{
+		Context:  context.TODO(),
+		Registry: registry.DefaultRegistry,
+	}
+
+	for _, opt := range opts {
+		opt(&sopts)
+	}
+
+	return &p2cSelector{
+		so:    sopts,
+		now:   time.Now,
+		stats: make(map[string]*stats),
+	}
+}
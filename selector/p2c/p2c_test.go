@@ -0,0 +1,156 @@
+package p2c
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+	"github.com/micro/go-plugins/registry/memory"
+)
+
+// testSelector returns a selector of n nodes with a clock which is moved by hand
+func testSelector(n int, opts ...selector.Option) (*p2cSelector, *time.Time) {
+	var nodes []*registry.Node
+	for i := 1; i <= n; i++ {
+		nodes = append(nodes, &registry.Node{
+			Id:      fmt.Sprintf("foo-%d", i),
+			Address: "localhost",
+			Port:    8080 + i,
+		})
+	}
+
+	r := memory.NewRegistry()
+	r.Register(&registry.Service{
+		Name:    "foo",
+		Version: "1.0.0",
+		Nodes:   nodes,
+	})
+
+	now := time.Now()
+
+	s := NewSelector(append(opts, selector.Registry(r))...).(*p2cSelector)
+	s.now = func() time.Time { return now }
+
+	return s, &now
+}
+
+// call sends requests one at a time, which take the latency and fail as given
+func call(t *testing.T, s *p2cSelector, now *time.Time, n int, latency map[string]time.Duration, fail map[string]bool) map[string]int {
+	counts := make(map[string]int)
+
+	for i := 0; i < n; i++ {
+		next, err := s.Select("foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		node, err := next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[node.Id]++
+
+		*now = now.Add(latency[node.Id])
+
+		var markErr error
+		if fail[node.Id] {
+			markErr = errors.New("error")
+		}
+		s.Mark("foo", node, markErr)
+	}
+
+	return counts
+}
+
+func TestLatency(t *testing.T) {
+	s, now := testSelector(3)
+
+	latency := map[string]time.Duration{
+		"foo-1": time.Millisecond,
+		"foo-2": time.Millisecond * 100,
+		"foo-3": time.Millisecond * 100,
+	}
+
+	counts := call(t, s, now, 300, latency, nil)
+
+	// the fast node wins whenever it's one of the two choices
+	if counts["foo-1"] < 170 {
+		t.Fatalf("Expected most requests to go to foo-1, got %v", counts)
+	}
+}
+
+func TestPenalty(t *testing.T) {
+	s, now := testSelector(2, Penalty(time.Second))
+
+	latency := map[string]time.Duration{
+		"foo-1": time.Millisecond,
+		"foo-2": time.Millisecond * 10,
+	}
+
+	// foo-1 fails fast but is penalised
+	counts := call(t, s, now, 100, latency, map[string]bool{"foo-1": true})
+
+	if counts["foo-1"] > 10 {
+		t.Fatalf("Expected few requests to go to the failing foo-1, got %v", counts)
+	}
+}
+
+func TestInFlight(t *testing.T) {
+	s, _ := testSelector(3)
+
+	next, err := s.Select("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without marks the least loaded node of the two is picked
+	counts := make(map[string]int)
+	for i := 0; i < 300; i++ {
+		node, err := next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[node.Id]++
+	}
+
+	for id, n := range counts {
+		if n < 90 || n > 110 {
+			t.Fatalf("Expected requests in flight to be balanced, %s has %d of %v", id, n, counts)
+		}
+	}
+
+	s.Reset("foo")
+
+	if len(s.stats) != 0 {
+		t.Fatalf("Expected stats to be reset, got %d", len(s.stats))
+	}
+}
+
+func TestPrune(t *testing.T) {
+	s, now := testSelector(3)
+
+	// every node has stats
+	call(t, s, now, 30, nil, nil)
+
+	s.so.Registry.Deregister(&registry.Service{
+		Name:    "foo",
+		Version: "1.0.0",
+		Nodes:   []*registry.Node{{Id: "foo-3"}},
+	})
+
+	if _, err := s.Select("foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Lock()
+	_, ok := s.stats["foo-3"]
+	n := len(s.stats)
+	s.Unlock()
+
+	if ok || n != 2 {
+		t.Fatalf("Expected the stats of foo-3 to be pruned, got %d stats", n)
+	}
+}
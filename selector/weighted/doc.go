@@ -0,0 +1,12 @@
+// Package weighted is a selector which balances requests by the weight of nodes.
+package weighted
+
+/*
+   A weighted selector. Each node is sent a share of requests in proportion to the weight
+   set in its metadata e.g weight=3 is sent three times the requests of weight=1. Nodes are
+   picked with a smooth weighted round robin by default, which spreads the picks of a heavy
+   node rather than sending them in a burst, or at random in proportion to their weight.
+
+   Services are cached with the cache registry, which watches the registry so changes in
+   weights are picked up straight away. The round robin starts over when the nodes change.
+*/
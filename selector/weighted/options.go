@@ -0,0 +1,39 @@
+package weighted
+
+import (
+	"github.com/micro/go-micro/selector"
+	"golang.org/x/net/context"
+)
+
+type randomKey struct{}
+type weightKey struct{}
+
+// RoundRobin picks nodes with a smooth weighted round robin, the default
+func RoundRobin() selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, randomKey{}, false)
+	}
+}
+
+// Random picks nodes at random in proportion to their weight
+func Random() selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, randomKey{}, true)
+	}
+}
+
+// WeightKey sets the metadata key of the weight of nodes, by default "weight"
+func WeightKey(k string) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, weightKey{}, k)
+	}
+}
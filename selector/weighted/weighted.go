@@ -0,0 +1,273 @@
+package weighted
+
+import (
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/cmd"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+	"github.com/micro/go-plugins/registry/cache"
+
+	"golang.org/x/net/context"
+)
+
+type weightedSelector struct {
+	so selector.Options
+
+	sync.Mutex
+	// rc caches the services of the registry
+	rc cache.Cache
+	// round robin state of each service
+	current map[string]*state
+}
+
+// state is the round robin state of the nodes of a service
+type state struct {
+	// weights the state is for, reset once they change
+	weights map[string]int
+	current map[string]int
+}
+
+// weighted is a node and its weight
+type weighted struct {
+	node   *registry.Node
+	weight int
+}
+
+var (
+	// DefaultWeightKey is the metadata key of the weight of nodes
+	DefaultWeightKey = "weight"
+	// DefaultWeight is the weight of nodes without a valid weight.
+	// Nodes with a weight of 0 are sent no requests.
+	DefaultWeight = 1
+)
+
+func init() {
+	rand.Seed(time.Now().UnixNano())
+	cmd.DefaultSelectors["weighted"] = NewSelector
+}
+
+// weight returns the weight of the node from its metadata
+func weight(node *registry.Node, key string) int {
+	if node.Metadata == nil {
+		return DefaultWeight
+	}
+
+	v, ok := node.Metadata[key]
+	if !ok {
+		return DefaultWeight
+	}
+
+	w, err := strconv.Atoi(v)
+	if err != nil || w < 0 {
+		return DefaultWeight
+	}
+
+	return w
+}
+
+// nodes flattens the services into the nodes with a weight above 0
+func nodes(services []*registry.Service, key string) []weighted {
+	var nodes []weighted
+
+	for _, service := range services {
+		for _, node := range service.Nodes {
+			if w := weight(node, key); w > 0 {
+				nodes = append(nodes, weighted{node, w})
+			}
+		}
+	}
+
+	return nodes
+}
+
+// random picks nodes at random in proportion to their weight
+func random(nodes []weighted) selector.Next {
+	var total int
+	for _, n := range nodes {
+		total += n.weight
+	}
+
+	return func() (*registry.Node, error) {
+		r := rand.Intn(total)
+		for _, n := range nodes {
+			if r < n.weight {
+				return n.node, nil
+			}
+			r -= n.weight
+		}
+		return nodes[len(nodes)-1].node, nil
+	}
+}
+
+// changed returns true if the nodes or their weights differ from the state
+func (s *state) changed(nodes []weighted) bool {
+	if len(s.weights) != len(nodes) {
+		return true
+	}
+	for _, n := range nodes {
+		if w, ok := s.weights[n.node.Id]; !ok || w != n.weight {
+			return true
+		}
+	}
+	return false
+}
+
+func newState(nodes []weighted) *state {
+	s := &state{
+		weights: make(map[string]int),
+		current: make(map[string]int),
+	}
+	for _, n := range nodes {
+		s.weights[n.node.Id] = n.weight
+	}
+	return s
+}
+
+// roundRobin picks nodes with a smooth weighted round robin. Every pick the
+// current weight of each node is increased by its weight, the node with the
+// highest current weight is picked and its current weight reduced by the total.
+func (w *weightedSelector) roundRobin(service string, nodes []weighted) selector.Next {
+	return func() (*registry.Node, error) {
+		w.Lock()
+		defer w.Unlock()
+
+		// start over when the nodes change
+		s, ok := w.current[service]
+		if !ok || s.changed(nodes) {
+			s = newState(nodes)
+			w.current[service] = s
+		}
+
+		var total int
+		var best *weighted
+
+		for i, n := range nodes {
+			s.current[n.node.Id] += n.weight
+			total += n.weight
+
+			if best == nil || s.current[n.node.Id] > s.current[best.node.Id] {
+				best = &nodes[i]
+			}
+		}
+
+		s.current[best.node.Id] -= total
+
+		return best.node, nil
+	}
+}
+
+func (w *weightedSelector) Init(opts ...selector.Option) error {
+	w.Lock()
+	defer w.Unlock()
+
+	r := w.so.Registry
+	for _, o := range opts {
+		o(&w.so)
+	}
+
+	// cache the new registry
+	if w.so.Registry != r {
+		w.rc.Stop()
+		w.rc = cache.New(w.so.Registry)
+	}
+
+	return nil
+}
+
+func (w *weightedSelector) Options() selector.Options {
+	w.Lock()
+	defer w.Unlock()
+	return w.so
+}
+
+func (w *weightedSelector) Select(service string, opts ...selector.SelectOption) (selector.Next, error) {
+	var sopts selector.SelectOptions
+	for _, opt := range opts {
+		opt(&sopts)
+	}
+
+	w.Lock()
+	rc, so := w.rc, w.so
+	w.Unlock()
+
+	// get the service
+	services, err := rc.GetService(service)
+	if err != nil {
+		return nil, err
+	}
+
+	// apply the filters
+	for _, filter := range sopts.Filters {
+		services = filter(services)
+	}
+
+	// if there's nothing left, return
+	if len(services) == 0 {
+		return nil, selector.ErrNotFound
+	}
+
+	// a strategy for the call takes precedence
+	if sopts.Strategy != nil {
+		return sopts.Strategy(services), nil
+	}
+
+	key, ok := so.Context.Value(weightKey{}).(string)
+	if !ok {
+		key = DefaultWeightKey
+	}
+
+	nodes := nodes(services, key)
+
+	// any nodes left?
+	if len(nodes) == 0 {
+		return nil, selector.ErrNoneAvailable
+	}
+
+	if r, _ := so.Context.Value(randomKey{}).(bool); r {
+		return random(nodes), nil
+	}
+
+	return w.roundRobin(service, nodes), nil
+}
+
+func (w *weightedSelector) Mark(service string, node *registry.Node, err error) {
+	return
+}
+
+func (w *weightedSelector) Reset(service string) {
+	w.Lock()
+	delete(w.current, service)
+	w.Unlock()
+}
+
+func (w *weightedSelector) Close() error {
+	w.Lock()
+	w.rc.Stop()
+	w.Unlock()
+	return nil
+}
+
+func (w *weightedSelector) String() string {
+	return "weighted"
+}
+
+func NewSelector(opts ...selector.Option) selector.Selector {
+	sopts := selector.Options{
+		Context:  context.TODO(),
+		Registry: registry.DefaultRegistry,
+	}
+
+	for _, opt := range opts {
+		opt(&sopts)
+	}
+
+	return &weightedSelector{
+		so:      sopts,
+		rc:      cache.New(sopts.Registry),
+		current: make(map[string]*state),
+	}
+}
@@ -0,0 +1,138 @@
+package weighted
+
+import (
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+	"github.com/micro/go-plugins/registry/memory"
+)
+
+func testNode(id, weight string) *registry.Node {
+	return &registry.Node{
+		Id:      id,
+		Address: "localhost",
+		Port:    8080,
+		Metadata: map[string]string{
+			"weight": weight,
+		},
+	}
+}
+
+func testRegistry() registry.Registry {
+	r := memory.NewRegistry()
+	r.Register(&registry.Service{
+		Name:    "foo",
+		Version: "1.0.0",
+		Nodes: []*registry.Node{
+			testNode("foo-1", "1"),
+			testNode("foo-2", "3"),
+			testNode("foo-3", "0"),
+		},
+	})
+	return r
+}
+
+func counts(t *testing.T, s selector.Selector, n int) map[string]int {
+	next, err := s.Select("foo")
+	if err != nil {
+		t.Fatalf("Unexpected error calling select: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		node, err := next()
+		if err != nil {
+			t.Fatalf("Expected node, got err: %v", err)
+		}
+		counts[node.Id]++
+	}
+	return counts
+}
+
+func TestRoundRobin(t *testing.T) {
+	s := NewSelector(selector.Registry(testRegistry()))
+	defer s.Close()
+
+	next, err := s.Select("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// picks of foo-2 are spread out
+	var picks []string
+	for i := 0; i < 4; i++ {
+		node, _ := next()
+		picks = append(picks, node.Id)
+	}
+
+	expected := []string{"foo-2", "foo-1", "foo-2", "foo-2"}
+	for i, id := range expected {
+		if picks[i] != id {
+			t.Fatalf("Expected picks %v, got %v", expected, picks)
+		}
+	}
+
+	// the round robin carries on across selects
+	c := counts(t, s, 400)
+	if c["foo-1"] != 100 || c["foo-2"] != 300 || c["foo-3"] != 0 {
+		t.Fatalf("Expected requests in proportion to weight, got %v", c)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	s := NewSelector(selector.Registry(testRegistry()), Random())
+	defer s.Close()
+
+	c := counts(t, s, 4000)
+
+	if c["foo-3"] != 0 {
+		t.Fatalf("Expected no requests to foo-3, got %d", c["foo-3"])
+	}
+
+	if c["foo-2"] < 2700 || c["foo-2"] > 3300 {
+		t.Fatalf("Expected about 3000 requests to foo-2, got %v", c)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	r := testRegistry()
+
+	s := NewSelector(selector.Registry(r), WeightKey("weight"))
+	defer s.Close()
+
+	// cache the service and wait for the cache to watch
+	counts(t, s, 1)
+	time.Sleep(time.Millisecond * 50)
+
+	r.Register(&registry.Service{
+		Name:    "foo",
+		Version: "1.0.0",
+		Nodes:   []*registry.Node{testNode("foo-3", "4")},
+	})
+
+	time.Sleep(time.Millisecond * 50)
+
+	if c := counts(t, s, 800); c["foo-3"] != 400 {
+		t.Fatalf("Expected half the requests to foo-3, got %v", c)
+	}
+}
+
+func TestInitRegistry(t *testing.T) {
+	s := NewSelector(selector.Registry(memory.NewRegistry()))
+	defer s.Close()
+
+	if _, err := s.Select("foo"); err == nil {
+		t.Fatal("Expected foo not to be found")
+	}
+
+	// the new registry is cached instead
+	if err := s.Init(selector.Registry(testRegistry())); err != nil {
+		t.Fatal(err)
+	}
+
+	if c := counts(t, s, 4); c["foo-1"] != 1 || c["foo-2"] != 3 {
+		t.Fatalf("Expected requests in proportion to weight, got %v", c)
+	}
+}
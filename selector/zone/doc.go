@@ -0,0 +1,11 @@
+// Package zone is a zone aware selector which fails over to the region and then any node.
+package zone
+
+/*
+   A zone aware selector. Requests are sent to nodes in the same zone as the caller while the zone
+   has enough healthy nodes, then to nodes in the same region, then to any node. The zone and region
+   of nodes are read from their metadata and the caller's from options or the environment.
+
+   A node is unhealthy while requests to it keep failing, or when the health registry marks
+   it unhealthy. The number of picks from each tier is counted to show how often it fails over.
+*/
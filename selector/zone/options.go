@@ -0,0 +1,64 @@
+package zone
+
+import (
+	"time"
+
+	"github.com/micro/go-micro/selector"
+	"golang.org/x/net/context"
+)
+
+type zoneKey struct{}
+type regionKey struct{}
+type minNodesKey struct{}
+type failuresKey struct{}
+type cooldownKey struct{}
+
+// Zone sets the zone of the caller, by default $MICRO_ZONE
+func Zone(z string) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, zoneKey{}, z)
+	}
+}
+
+// Region sets the region of the caller, by default $MICRO_REGION
+func Region(r string) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, regionKey{}, r)
+	}
+}
+
+// MinNodes sets the number of healthy nodes a tier needs to be used
+func MinNodes(n int) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, minNodesKey{}, n)
+	}
+}
+
+// Failures sets the number of failed requests in a row before a node is unhealthy
+func Failures(n int) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, failuresKey{}, n)
+	}
+}
+
+// Cooldown sets how long a node is unhealthy after its last failed request
+func Cooldown(d time.Duration) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, cooldownKey{}, d)
+	}
+}
@@ -0,0 +1,301 @@
+package zone
+
+import (
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/cmd"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+	"github.com/micro/go-plugins/registry/health"
+
+	"golang.org/x/net/context"
+)
+
+// Selector is a zone aware selector which counts the picks from each tier
+type Selector interface {
+	selector.Selector
+	// Stats returns the number of picks from each tier
+	Stats() Stats
+}
+
+// Stats are the number of nodes picked from each tier
+type Stats struct {
+	Zone   uint64
+	Region uint64
+	Any    uint64
+}
+
+type zoneSelector struct {
+	so selector.Options
+
+	sync.Mutex
+	failures map[string]*failure
+	stats    Stats
+}
+
+// failure is the consecutive failed requests to a node
+type failure struct {
+	service string
+	count   int
+	last    time.Time
+}
+
+type tier int
+
+const (
+	tierZone tier = iota
+	tierRegion
+	tierAny
+)
+
+var (
+	// DefaultMinNodes is the number of healthy nodes a tier needs to be used
+	DefaultMinNodes = 1
+	// DefaultFailures is the number of failed requests in a row before a node is unhealthy
+	DefaultFailures = 3
+	// DefaultCooldown is how long a node is unhealthy after its last failed request
+	DefaultCooldown = time.Second * 30
+
+	// ZoneKey and RegionKey are the node metadata keys of the zone and region
+	ZoneKey   = "zone"
+	RegionKey = "region"
+)
+
+func init() {
+	rand.Seed(time.Now().UnixNano())
+	cmd.DefaultSelectors["zone"] = NewSelector
+}
+
+func (z *zoneSelector) zone() string {
+	if v, ok := z.so.Context.Value(zoneKey{}).(string); ok {
+		return v
+	}
+	return os.Getenv("MICRO_ZONE")
+}
+
+func (z *zoneSelector) region() string {
+	if v, ok := z.so.Context.Value(regionKey{}).(string); ok {
+		return v
+	}
+	return os.Getenv("MICRO_REGION")
+}
+
+func (z *zoneSelector) minNodes() int {
+	if n, ok := z.so.Context.Value(minNodesKey{}).(int); ok && n > 0 {
+		return n
+	}
+	return DefaultMinNodes
+}
+
+func (z *zoneSelector) maxFailures() int {
+	if n, ok := z.so.Context.Value(failuresKey{}).(int); ok && n > 0 {
+		return n
+	}
+	return DefaultFailures
+}
+
+func (z *zoneSelector) cooldown() time.Duration {
+	if d, ok := z.so.Context.Value(cooldownKey{}).(time.Duration); ok && d > 0 {
+		return d
+	}
+	return DefaultCooldown
+}
+
+// healthy returns the nodes which aren't failing or marked
+// unhealthy by the health registry, the lock must be held
+func (z *zoneSelector) healthy(nodes []*registry.Node) []*registry.Node {
+	max, cooldown := z.maxFailures(), z.cooldown()
+
+	var healthy []*registry.Node
+
+	for _, node := range nodes {
+		if node.Metadata != nil && node.Metadata[health.MetadataKey] == health.Unhealthy {
+			continue
+		}
+
+		if f, ok := z.failures[node.Id]; ok && f.count >= max && time.Since(f.last) < cooldown {
+			continue
+		}
+
+		healthy = append(healthy, node)
+	}
+
+	return healthy
+}
+
+func match(nodes []*registry.Node, key, val string) []*registry.Node {
+	if len(val) == 0 {
+		return nil
+	}
+
+	var matched []*registry.Node
+	for _, node := range nodes {
+		if node.Metadata != nil && node.Metadata[key] == val {
+			matched = append(matched, node)
+		}
+	}
+	return matched
+}
+
+// tier returns the nodes of the first tier with enough healthy nodes
+func (z *zoneSelector) tier(nodes []*registry.Node) (tier, []*registry.Node) {
+	min := z.minNodes()
+
+	z.Lock()
+	healthy := z.healthy(nodes)
+	z.Unlock()
+
+	if local := match(healthy, ZoneKey, z.zone()); len(local) >= min {
+		return tierZone, local
+	}
+
+	if regional := match(healthy, RegionKey, z.region()); len(regional) >= min {
+		return tierRegion, regional
+	}
+
+	// all the nodes rather than none if none are healthy
+	if len(healthy) == 0 {
+		return tierAny, nodes
+	}
+
+	return tierAny, healthy
+}
+
+func (z *zoneSelector) next(t tier, nodes []*registry.Node) selector.Next {
+	return func() (*registry.Node, error) {
+		z.Lock()
+		switch t {
+		case tierZone:
+			z.stats.Zone++
+		case tierRegion:
+			z.stats.Region++
+		default:
+			z.stats.Any++
+		}
+		z.Unlock()
+
+		return nodes[rand.Int()%len(nodes)], nil
+	}
+}
+
+func (z *zoneSelector) Init(opts ...selector.Option) error {
+	for _, o := range opts {
+		o(&z.so)
+	}
+	return nil
+}
+
+func (z *zoneSelector) Options() selector.Options {
+	return z.so
+}
+
+func (z *zoneSelector) Select(service string, opts ...selector.SelectOption) (selector.Next, error) {
+	var sopts selector.SelectOptions
+	for _, opt := range opts {
+		opt(&sopts)
+	}
+
+	// get the service
+	services, err := z.so.Registry.GetService(service)
+	if err != nil {
+		return nil, err
+	}
+
+	// apply the filters
+	for _, filter := range sopts.Filters {
+		services = filter(services)
+	}
+
+	// if there's nothing left, return
+	if len(services) == 0 {
+		return nil, selector.ErrNotFound
+	}
+
+	var nodes []*registry.Node
+
+	// flatten node list
+	for _, service := range services {
+		for _, node := range service.Nodes {
+			nodes = append(nodes, node)
+		}
+	}
+
+	// any nodes left?
+	if len(nodes) == 0 {
+		return nil, selector.ErrNoneAvailable
+	}
+
+	t, nodes := z.tier(nodes)
+
+	// a strategy for the call picks from the tier
+	if sopts.Strategy != nil {
+		return sopts.Strategy([]*registry.Service{{Name: service, Nodes: nodes}}), nil
+	}
+
+	return z.next(t, nodes), nil
+}
+
+// Mark counts the failed requests in a row to the node
+func (z *zoneSelector) Mark(service string, node *registry.Node, err error) {
+	z.Lock()
+	defer z.Unlock()
+
+	if err == nil {
+		delete(z.failures, node.Id)
+		return
+	}
+
+	f, ok := z.failures[node.Id]
+	if !ok {
+		f = &failure{service: service}
+		z.failures[node.Id] = f
+	}
+
+	f.count++
+	f.last = time.Now()
+}
+
+func (z *zoneSelector) Reset(service string) {
+	z.Lock()
+	defer z.Unlock()
+
+	for id, f := range z.failures {
+		if f.service == service {
+			delete(z.failures, id)
+		}
+	}
+}
+
+func (z *zoneSelector) Stats() Stats {
+	z.Lock()
+	defer z.Unlock()
+	return z.stats
+}
+
+func (z *zoneSelector) Close() error {
+	return nil
+}
+
+func (z *zoneSelector) String() string {
+	return "zone"
+}
+
+// NewSelector returns a zone aware selector, which implements Selector
+func NewSelector(opts ...selector.Option) selector.Selector {
+	sopts := selector.Options{
+		Context:  context.TODO(),
+		Registry: registry.DefaultRegistry,
+	}
+
+	for _, opt := range opts {
+		opt(&sopts)
+	}
+
+	return &zoneSelector{
+		so:       sopts,
+		failures: make(map[string]*failure),
+	}
+}
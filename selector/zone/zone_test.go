@@ -0,0 +1,143 @@
+package zone
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+	"github.com/micro/go-plugins/registry/health"
+	"github.com/micro/go-plugins/registry/memory"
+)
+
+func testNode(id, zone, region string) *registry.Node {
+	return &registry.Node{
+		Id:      id,
+		Address: "localhost",
+		Port:    8080,
+		Metadata: map[string]string{
+			"zone":   zone,
+			"region": region,
+		},
+	}
+}
+
+func testSelector(opts ...selector.Option) Selector {
+	r := memory.NewRegistry()
+	r.Register(&registry.Service{
+		Name:    "foo",
+		Version: "1.0.0",
+		Nodes: []*registry.Node{
+			testNode("foo-1", "eu-west-1a", "eu-west-1"),
+			testNode("foo-2", "eu-west-1b", "eu-west-1"),
+			testNode("foo-3", "us-east-1a", "us-east-1"),
+		},
+	})
+
+	opts = append(opts, selector.Registry(r), Zone("eu-west-1a"), Region("eu-west-1"))
+	return NewSelector(opts...).(Selector)
+}
+
+func picks(t *testing.T, s selector.Selector, n int) map[string]int {
+	next, err := s.Select("foo")
+	if err != nil {
+		t.Fatalf("Unexpected error calling select: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		node, err := next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[node.Id]++
+	}
+	return counts
+}
+
+func TestZone(t *testing.T) {
+	s := testSelector()
+
+	if c := picks(t, s, 10); c["foo-1"] != 10 {
+		t.Fatalf("Expected every pick in the zone, got %v", c)
+	}
+
+	// fail over to the region once the zone is unhealthy
+	for i := 0; i < DefaultFailures; i++ {
+		s.Mark("foo", &registry.Node{Id: "foo-1"}, errors.New("error"))
+	}
+
+	if c := picks(t, s, 10); c["foo-2"] != 10 {
+		t.Fatalf("Expected every pick in the region, got %v", c)
+	}
+
+	// and then anywhere
+	for i := 0; i < DefaultFailures; i++ {
+		s.Mark("foo", &registry.Node{Id: "foo-2"}, errors.New("error"))
+	}
+
+	if c := picks(t, s, 10); c["foo-3"] != 10 {
+		t.Fatalf("Expected every pick outside the region, got %v", c)
+	}
+
+	// a success makes the zone healthy again
+	s.Mark("foo", &registry.Node{Id: "foo-1"}, nil)
+
+	if c := picks(t, s, 10); c["foo-1"] != 10 {
+		t.Fatalf("Expected every pick in the zone, got %v", c)
+	}
+
+	expected := Stats{Zone: 20, Region: 10, Any: 10}
+	if stats := s.Stats(); stats != expected {
+		t.Fatalf("Expected stats %+v, got %+v", expected, stats)
+	}
+}
+
+func TestMinNodes(t *testing.T) {
+	// the zone has too few nodes
+	s := testSelector(MinNodes(2))
+
+	if c := picks(t, s, 100); c["foo-3"] > 0 || c["foo-1"] == 0 || c["foo-2"] == 0 {
+		t.Fatalf("Expected picks across the region, got %v", c)
+	}
+
+	if stats := s.Stats(); stats.Region != 100 {
+		t.Fatalf("Expected 100 picks from the region, got %+v", stats)
+	}
+}
+
+func TestFailures(t *testing.T) {
+	s := testSelector(Failures(1), Cooldown(time.Millisecond*50))
+
+	// one failure makes the node unhealthy
+	s.Mark("foo", &registry.Node{Id: "foo-1"}, errors.New("error"))
+
+	if c := picks(t, s, 10); c["foo-2"] != 10 {
+		t.Fatalf("Expected every pick in the region, got %v", c)
+	}
+
+	// until the cooldown has passed
+	time.Sleep(time.Millisecond * 60)
+
+	if c := picks(t, s, 10); c["foo-1"] != 10 {
+		t.Fatalf("Expected every pick in the zone, got %v", c)
+	}
+}
+
+func TestHealthMetadata(t *testing.T) {
+	node := testNode("foo-1", "eu-west-1a", "eu-west-1")
+	node.Metadata[health.MetadataKey] = health.Unhealthy
+
+	r := memory.NewRegistry()
+	r.Register(&registry.Service{
+		Name:  "foo",
+		Nodes: []*registry.Node{node, testNode("foo-2", "eu-west-1b", "eu-west-1")},
+	})
+
+	s := NewSelector(selector.Registry(r), Zone("eu-west-1a"), Region("eu-west-1"))
+
+	if c := picks(t, s, 10); c["foo-2"] != 10 {
+		t.Fatalf("Expected every pick in the region, got %v", c)
+	}
+}